@@ -0,0 +1,361 @@
+// Package cli implements sfxr-go's headless subcommands. It only depends on
+// the sfxr package, so it builds and runs without SDL, a display or an audio
+// device.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arthrp/sfxr-go/sfxr"
+)
+
+type command struct {
+	Name  string
+	Usage string
+	Run   func(args []string) error
+}
+
+var commands = []command{
+	{"render", "render in.cfg [-o out.wav] [-rate 44100] [-bits 16] [-gain 4] [-dither none] [-peak dB | -lufs LUFS] [-trim dB] [-fade-in 0s] [-fade-out 0s] [-report] [-seed n]", renderCommand},
+	{"generate", "generate -category laser [-count 1] [-seed n] [-out dir] [-format cfg] [-rate 44100] [-bits 16] [-gain 4] [-dither none] [-peak dB | -lufs LUFS] [-trim dB] [-fade-in 0s] [-fade-out 0s] [-report]", generateCommand},
+	{"convert", "convert in.cfg out.json", convertCommand},
+	{"import", "import <jsfxr/bfxr string, sfxr.me URL or file> -o out.cfg", importCommand},
+	{"share", "share in.cfg [-json]", shareCommand},
+}
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "usage: %s command [arguments]\n\ncommands:\n", name)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", name, c.Usage)
+	}
+}
+
+// Run executes the subcommand named by args[0] with the remaining arguments.
+// It returns flag.ErrHelp after printing the usage for -h.
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage()
+		return flag.ErrHelp
+	}
+	for _, c := range commands {
+		if c.Name == args[0] {
+			return c.Run(args[1:])
+		}
+	}
+	usage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// parseArgs parses flags that may be interleaved with positional arguments,
+// e.g. "in.cfg -o out.wav", and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func warn(warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+}
+
+// loadSettings loads a settings file and prints any warnings about values
+// that had to be clamped.
+func loadSettings(p *sfxr.Params, filename string) error {
+	warnings, err := p.LoadSettings(filename)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s: %s\n", filename, w)
+	}
+	return nil
+}
+
+// exportFlags are the WAV export options shared by render and generate.
+type exportFlags struct {
+	fs      *flag.FlagSet
+	rate    *int
+	bits    *int
+	gain    *float64
+	dither  *string
+	peak    *float64
+	lufs    *float64
+	trim    *float64
+	fadeIn  *time.Duration
+	fadeOut *time.Duration
+	report  *bool
+}
+
+func addExportFlags(fs *flag.FlagSet) *exportFlags {
+	return &exportFlags{
+		fs:      fs,
+		rate:    fs.Int("rate", 44100, "sample rate in Hz, e.g. 22050, 44100 or 48000"),
+		bits:    fs.Int("bits", 16, "bits per sample: 8, 16, 24 or 32 (float)"),
+		gain:    fs.Float64("gain", sfxr.DefaultGain, "gain applied before clipping and quantization"),
+		dither:  fs.String("dither", "none", "dither for 8 to 24-bit output: none, tpdf or shaped (TPDF with noise shaping)"),
+		peak:    fs.Float64("peak", 0, "normalize the peak to this level in dBFS"),
+		lufs:    fs.Float64("lufs", 0, "normalize the integrated loudness to this level in LUFS"),
+		trim:    fs.Float64("trim", 0, "trim leading and trailing silence below this level in dBFS, e.g. -60"),
+		fadeIn:  fs.Duration("fade-in", 0, "length of a fade-in ramp, e.g. 5ms"),
+		fadeOut: fs.Duration("fade-out", 0, "length of a fade-out ramp, e.g. 20ms"),
+		report:  fs.Bool("report", false, "print the peak, RMS and loudness of each exported sound"),
+	}
+}
+
+func (e *exportFlags) check() error {
+	if *e.rate < sfxr.MinSampleRate || *e.rate > sfxr.MaxSampleRate {
+		return fmt.Errorf("unsupported sample rate %d (want %d to %d)", *e.rate, sfxr.MinSampleRate, sfxr.MaxSampleRate)
+	}
+	if *e.bits != 8 && *e.bits != 16 && *e.bits != 24 && *e.bits != 32 {
+		return fmt.Errorf("unsupported bit depth %d (want 8, 16, 24 or 32)", *e.bits)
+	}
+	if *e.gain <= 0 {
+		return fmt.Errorf("gain must be positive")
+	}
+	if _, ok := sfxr.ParseDither(*e.dither); !ok {
+		return fmt.Errorf("unknown dither %q", *e.dither)
+	}
+	if isSet(e.fs, "peak") && isSet(e.fs, "lufs") {
+		return fmt.Errorf("-peak and -lufs cannot be used together")
+	}
+	if *e.trim > 0 {
+		return fmt.Errorf("trim threshold must be below 0 dBFS")
+	}
+	if *e.fadeIn < 0 || *e.fadeOut < 0 {
+		return fmt.Errorf("fade lengths cannot be negative")
+	}
+	return nil
+}
+
+// synth returns a Synth for p with the export options applied.
+func (e *exportFlags) synth(p sfxr.Params) *sfxr.Synth {
+	s := sfxr.NewSynth(p)
+	s.Gain = float32(*e.gain)
+	s.Dither, _ = sfxr.ParseDither(*e.dither)
+	if isSet(e.fs, "peak") {
+		s.Normalize, s.NormalizeTarget = sfxr.NormalizePeak, *e.peak
+	}
+	if isSet(e.fs, "lufs") {
+		s.Normalize, s.NormalizeTarget = sfxr.NormalizeLoudness, *e.lufs
+	}
+	s.TrimThreshold = *e.trim
+	s.FadeIn, s.FadeOut = *e.fadeIn, *e.fadeOut
+	return s
+}
+
+// export renders p to filename, or to standard output if filename is "-",
+// and reports on the result.
+func (e *exportFlags) export(p sfxr.Params, filename string) error {
+	s := e.synth(p)
+	var err error
+	if filename == "-" {
+		err = s.WriteWAV(os.Stdout, *e.rate, *e.bits)
+	} else {
+		err = s.ExportWAV(filename, *e.rate, *e.bits)
+	}
+	if err != nil {
+		return err
+	}
+	r := s.Report()
+	if r.Truncated {
+		warn([]string{fmt.Sprintf("%s: sound cut short after %d samples", filename, r.Samples)})
+	}
+	if r.Clipped > 0 {
+		warn([]string{fmt.Sprintf("%s: %d of %d samples clipped, peaking at %+.1f dBFS before clipping",
+			filename, r.Clipped, r.Samples, 20*math.Log10(float64(r.MaxLevel)))})
+	}
+	if *e.report {
+		fmt.Fprintf(os.Stderr, "%s: peak %.1f dBFS, RMS %.1f dBFS, loudness %.1f LUFS, %d samples clipped\n",
+			filename, r.Levels.Peak, r.Levels.RMS, r.Levels.Loudness, r.Clipped)
+	}
+	return nil
+}
+
+func renderCommand(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	out := fs.String("o", "", "output .wav file, or - for standard output (default: input name with .wav extension)")
+	export := addExportFlags(fs)
+	seed := fs.Int64("seed", 0, "noise seed (default: the seed stored in the file)")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("render: expected exactly one input file")
+	}
+	if err := export.check(); err != nil {
+		return err
+	}
+
+	in := files[0]
+	if *out == "" {
+		*out = strings.TrimSuffix(in, filepath.Ext(in)) + ".wav"
+	}
+
+	p := sfxr.DefaultParams()
+	if err := loadSettings(&p, in); err != nil {
+		return err
+	}
+	if isSet(fs, "seed") {
+		p.Seed = *seed
+	}
+	return export.export(p, *out)
+}
+
+func generateCommand(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	name := fs.String("category", "", "sound category: pickup, laser, explosion, powerup, hit, jump or blip")
+	count := fs.Int("count", 1, "number of sounds to generate")
+	seed := fs.Int64("seed", 0, "random seed (default: based on the current time)")
+	dir := fs.String("out", ".", "output directory")
+	format := fs.String("format", "cfg", "settings format: cfg or json")
+	export := addExportFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	category, ok := sfxr.ParseCategory(*name)
+	if !ok {
+		return fmt.Errorf("unknown category %q", *name)
+	}
+	if *format != "cfg" && *format != "json" {
+		return fmt.Errorf("unknown settings format %q", *format)
+	}
+	if *count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	if err := export.check(); err != nil {
+		return err
+	}
+	if !isSet(fs, "seed") {
+		*seed = time.Now().UnixNano()
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	prefix := strings.ToLower(strings.Split(category.String(), "/")[0])
+	width := len(fmt.Sprint(*count - 1))
+	if width < 3 {
+		width = 3
+	}
+	g := sfxr.NewGenerator(*seed)
+	for i := 0; i < *count; i++ {
+		base := filepath.Join(*dir, fmt.Sprintf("%s_%0*d", prefix, width, i))
+		p := sfxr.DefaultParams()
+		g.Generate(&p, category)
+		if err := p.SaveSettings(base + "." + *format); err != nil {
+			return err
+		}
+		if err := export.export(p, base+".wav"); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "generated %d %s sounds in %s (seed %d)\n", *count, prefix, *dir, *seed)
+	return nil
+}
+
+// convertCommand rewrites a settings file in the format implied by the
+// output file extension (.json for JSON, anything else for binary .cfg).
+func convertCommand(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 2 {
+		return fmt.Errorf("convert: expected an input and an output file")
+	}
+	p := sfxr.DefaultParams()
+	if err := loadSettings(&p, files[0]); err != nil {
+		return err
+	}
+	if err := p.SaveSettings(files[1]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	out := fs.String("o", "", "output settings file (.cfg or .json)")
+	inputs, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) != 1 || *out == "" {
+		return fmt.Errorf("import: expected one share string or file and an -o output")
+	}
+	text := inputs[0]
+	if data, err := os.ReadFile(text); err == nil {
+		text = string(data)
+	}
+	var p sfxr.Params
+	warnings, err := p.ImportJsfxr(text)
+	if err != nil {
+		var berr error
+		warnings, berr = p.ImportBfxr(text)
+		if berr != nil {
+			return fmt.Errorf("not a jsfxr or bfxr sound (jsfxr: %v; bfxr: %v)", err, berr)
+		}
+	}
+	warn(warnings)
+	if err := p.SaveSettings(*out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// shareCommand prints a jsfxr share string that can be pasted into sfxr.me.
+func shareCommand(args []string) error {
+	fs := flag.NewFlagSet("share", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print a jsfxr JSON object instead of a base58 string")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("share: expected exactly one input file")
+	}
+	p := sfxr.DefaultParams()
+	if err := loadSettings(&p, files[0]); err != nil {
+		return err
+	}
+	var share string
+	var warnings []string
+	if *asJSON {
+		share, warnings = p.JsfxrJSON()
+	} else {
+		share, warnings = p.JsfxrB58()
+	}
+	warn(warnings)
+	fmt.Println(share)
+	return nil
+}
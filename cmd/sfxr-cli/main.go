@@ -0,0 +1,21 @@
+// Command sfxr-cli runs sfxr-go's headless subcommands without the editor, so
+// it can be built where SDL is not available, e.g. to render sounds in CI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arthrp/sfxr-go/cmd/cli"
+)
+
+func main() {
+	if err := cli.Run(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "sfxr-cli:", err)
+		os.Exit(1)
+	}
+}
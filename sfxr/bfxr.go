@@ -0,0 +1,182 @@
+package sfxr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Bfxr stores its parameters either as a comma-separated settings string
+// (also the contents of a .bfxrsound file) or as a JSON object keyed by
+// parameter name. bfxrFields lists the parameters in settings string order;
+// anything after them (bfxr's slider locks) is ignored.
+var bfxrFields = []string{
+	"waveType",
+	"masterVolume",
+	"attackTime",
+	"sustainTime",
+	"sustainPunch",
+	"decayTime",
+	"compressionAmount",
+	"startFrequency",
+	"minFrequency",
+	"slide",
+	"deltaSlide",
+	"vibratoDepth",
+	"vibratoSpeed",
+	"overtones",
+	"overtoneFalloff",
+	"changeRepeat",
+	"changeAmount",
+	"changeSpeed",
+	"changeAmount2",
+	"changeSpeed2",
+	"squareDuty",
+	"dutySweep",
+	"repeatSpeed",
+	"flangerOffset",
+	"flangerSweep",
+	"lpFilterCutoff",
+	"lpFilterCutoffSweep",
+	"lpFilterResonance",
+	"hpFilterCutoff",
+	"hpFilterCutoffSweep",
+	"bitCrush",
+	"bitCrushSweep",
+}
+
+// bfxrUnsupported lists bfxr parameters with no sfxr equivalent. A parameter
+// is only reported as dropped when its value would have changed the sound;
+// "requires" names a parameter that must be non-zero for it to matter.
+var bfxrUnsupported = []struct {
+	name     string
+	requires string
+}{
+	{"overtones", ""},
+	{"overtoneFalloff", "overtones"},
+	{"changeRepeat", ""},
+	{"changeAmount2", ""},
+	{"changeSpeed2", "changeAmount2"},
+}
+
+func parseBfxrString(s string) (map[string]float64, error) {
+	fields := strings.Split(strings.TrimSpace(s), ",")
+	if len(fields) < len(bfxrFields) {
+		return nil, fmt.Errorf("bfxr settings string has %d values, want %d", len(fields), len(bfxrFields))
+	}
+	values := make(map[string]float64, len(bfxrFields))
+	for i, name := range bfxrFields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bfxr %s %q", name, fields[i])
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+func parseBfxrJSON(data []byte) (map[string]float64, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid bfxr JSON: %w", err)
+	}
+	if _, ok := raw["startFrequency"]; !ok {
+		return nil, errors.New("JSON object is not a bfxr sound")
+	}
+	values := make(map[string]float64, len(bfxrFields))
+	for _, name := range bfxrFields {
+		if msg, ok := raw[name]; ok {
+			var v float64
+			if json.Unmarshal(msg, &v) != nil {
+				return nil, fmt.Errorf("invalid bfxr %s %s", name, msg)
+			}
+			values[name] = v
+		}
+	}
+	return values, nil
+}
+
+// ImportBfxr replaces p with a sound from a bfxr settings string or bfxr JSON
+// object. Parameters shared with sfxr are copied as is. It returns a warning
+// for each bfxr parameter that affected the sound but has no sfxr equivalent,
+// and for each value that had to be clamped.
+func (p *Params) ImportBfxr(s string) (warnings []string, err error) {
+	var values map[string]float64
+	if isJSON([]byte(s)) {
+		values, err = parseBfxrJSON([]byte(s))
+	} else {
+		values, err = parseBfxrString(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q := DefaultParams()
+	set := func(name string, dst *float32) {
+		if v, ok := values[name]; ok {
+			*dst = float32(v)
+		}
+	}
+	if v, ok := values["waveType"]; ok {
+		q.WaveType = int(v) // bfxr numbers its waveforms as we do
+	}
+	set("masterVolume", &q.SoundVol)
+	set("attackTime", &q.EnvAttack)
+	set("sustainTime", &q.EnvSustain)
+	set("sustainPunch", &q.EnvPunch)
+	set("decayTime", &q.EnvDecay)
+	set("startFrequency", &q.BaseFreq)
+	set("minFrequency", &q.FreqLimit)
+	set("slide", &q.FreqRamp)
+	set("deltaSlide", &q.FreqDramp)
+	set("vibratoDepth", &q.VibStrength)
+	set("vibratoSpeed", &q.VibSpeed)
+	set("changeAmount", &q.ArpMod)
+	set("changeSpeed", &q.ArpSpeed)
+	set("squareDuty", &q.Duty)
+	set("dutySweep", &q.DutyRamp)
+	set("repeatSpeed", &q.RepeatSpeed)
+	set("flangerOffset", &q.PhaOffset)
+	set("flangerSweep", &q.PhaRamp)
+	set("lpFilterCutoff", &q.LpfFreq)
+	set("lpFilterCutoffSweep", &q.LpfRamp)
+	set("lpFilterResonance", &q.LpfResonance)
+	set("hpFilterCutoff", &q.HpfFreq)
+	set("hpFilterCutoffSweep", &q.HpfRamp)
+	set("bitCrush", &q.CrushRate)
+	set("bitCrushSweep", &q.CrushRateRamp)
+	set("compressionAmount", &q.Compression)
+
+	for _, u := range bfxrUnsupported {
+		if values[u.name] == 0 {
+			continue
+		}
+		if u.requires != "" && values[u.requires] == 0 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("unsupported bfxr parameter %s dropped", u.name))
+	}
+
+	*p = q
+	return append(warnings, p.Sanitize()...), nil
+}
+
+// LoadBfxr reads a .bfxrsound file. See ImportBfxr.
+func (p *Params) LoadBfxr(filename string) (warnings []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := readLimited(file)
+	if err == nil {
+		warnings, err = p.ImportBfxr(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return warnings, nil
+}
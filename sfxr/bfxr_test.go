@@ -0,0 +1,105 @@
+package sfxr
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// bfxrSound is a bfxr settings string with a distinct value for each field,
+// followed by slider locks, which are ignored.
+const bfxrSound = "4,0.6,0.01,0.2,0.3,0.4,0.05,0.5,0.1,-0.2,0.02,0.15,0.25,0.3,0.2,0," +
+	"0.35,0.45,0,0.5,0.55,-0.1,0.12,0.08,-0.03,0.9,0.04,0.22,0.06,-0.07,0,0," +
+	"True,False,True"
+
+func TestImportBfxr(t *testing.T) {
+	var p Params
+	warnings, err := p.ImportBfxr(bfxrSound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultParams()
+	want.WaveType = 4
+	want.SoundVol = 0.6
+	want.EnvAttack = 0.01
+	want.EnvSustain = 0.2
+	want.EnvPunch = 0.3
+	want.EnvDecay = 0.4
+	want.Compression = 0.05
+	want.BaseFreq = 0.5
+	want.FreqLimit = 0.1
+	want.FreqRamp = -0.2
+	want.FreqDramp = 0.02
+	want.VibStrength = 0.15
+	want.VibSpeed = 0.25
+	want.ArpMod = 0.35
+	want.ArpSpeed = 0.45
+	want.Duty = 0.55
+	want.DutyRamp = -0.1
+	want.RepeatSpeed = 0.12
+	want.PhaOffset = 0.08
+	want.PhaRamp = -0.03
+	want.LpfFreq = 0.9
+	want.LpfRamp = 0.04
+	want.LpfResonance = 0.22
+	want.HpfFreq = 0.06
+	want.HpfRamp = -0.07
+	if p != want {
+		t.Errorf("imported %+v\nwant %+v", p, want)
+	}
+
+	// changeSpeed2 is set but does nothing without changeAmount2.
+	wantWarnings := []string{
+		"unsupported bfxr parameter overtones dropped",
+		"unsupported bfxr parameter overtoneFalloff dropped",
+	}
+	if !slices.Equal(warnings, wantWarnings) {
+		t.Errorf("warnings %q, want %q", warnings, wantWarnings)
+	}
+}
+
+func TestImportBfxrShort(t *testing.T) {
+	var p Params
+	if _, err := p.ImportBfxr("0,0.5,0,0.3"); err == nil {
+		t.Fatal("short settings string accepted")
+	}
+}
+
+func TestImportBfxrJSON(t *testing.T) {
+	var p Params
+	warnings, err := p.ImportBfxr(`{"waveType": 1, "startFrequency": 0.4, "overtones": 0.2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.WaveType != 1 || p.BaseFreq != 0.4 {
+		t.Errorf("imported wave_type %d, base_freq %v", p.WaveType, p.BaseFreq)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings %q, want one for overtones", warnings)
+	}
+}
+
+func TestImportBfxrEffects(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		value float32
+		field func(*Params) *float32
+	}{
+		{"bitCrush", 0.5, func(p *Params) *float32 { return &p.CrushRate }},
+		{"bitCrushSweep", -0.2, func(p *Params) *float32 { return &p.CrushRateRamp }},
+		{"compressionAmount", 0.3, func(p *Params) *float32 { return &p.Compression }},
+	} {
+		var p Params
+		warnings, err := p.ImportBfxr(fmt.Sprintf(`{"startFrequency": 0.4, %q: %v}`, tt.name, tt.value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) > 0 {
+			t.Errorf("%s: unexpected warnings: %v", tt.name, warnings)
+		}
+		if got := *tt.field(&p); got != tt.value {
+			t.Errorf("%s %v imported as %v", tt.name, tt.value, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package sfxr
+
+import "math"
+
+// polyBLEP returns the polynomial band-limited step correction for a unit
+// rise at phase 0 of an oscillator at phase t that advances by dt per
+// sample. It is non-zero only within one sample of the step.
+func polyBLEP(t, dt float64) float64 {
+	switch {
+	case t < dt:
+		t /= dt
+		return t + t - t*t - 1
+	case t > 1-dt:
+		t = (t - 1) / dt
+		return t*t + t + t + 1
+	}
+	return 0
+}
+
+// bandLimited advances the band-limited oscillator by one supersample of the
+// given period and returns its square or sawtooth sample. Unlike the classic
+// oscillator, its phase is not rounded to whole supersamples.
+func (s *Synth) bandLimited(period float64) float32 {
+	dt := 1 / max(period, 8)
+	s.osc_phase += dt
+	s.osc_phase -= math.Floor(s.osc_phase)
+	t := s.osc_phase
+	if s.Params.WaveType == 1 {
+		return float32(1 - 2*t + polyBLEP(t, dt))
+	}
+	duty := float64(s.square_duty)
+	v := -0.5
+	if t < duty {
+		v = 0.5
+	}
+	return float32(v + 0.5*polyBLEP(t, dt) - 0.5*polyBLEP(math.Mod(t-duty+1, 1), dt))
+}
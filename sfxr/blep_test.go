@@ -0,0 +1,55 @@
+package sfxr
+
+import (
+	"math"
+	"testing"
+)
+
+// aliasing renders a steady, high sawtooth or square and returns the share of
+// its power that lies away from the harmonics of its pitch.
+func aliasing(waveType int, bandLimited bool) float64 {
+	p := DefaultParams()
+	p.WaveType = waveType
+	p.BaseFreq = 0.79
+	p.Duty = 0.3
+	p.EnvSustain = 0.5
+	p.EnvDecay = 0
+	p.BandLimited = bandLimited
+	const n = 2048
+	samples := playback(p, 1000+n)[1000:]
+
+	// The classic oscillator rounds its period to whole supersamples.
+	period := 100.0 / (float64(p.BaseFreq*p.BaseFreq) + 0.001)
+	if !bandLimited {
+		period = math.Floor(period)
+	}
+	f0 := 44100 * 8 / period
+
+	var total, alias float64
+	for k := 1; k < n/2; k++ {
+		var re, im float64
+		for i, v := range samples {
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/n) // Hann window
+			a := 2 * math.Pi * float64(k*i) / n
+			re += float64(v) * w * math.Cos(a)
+			im -= float64(v) * w * math.Sin(a)
+		}
+		power := re*re + im*im
+		total += power
+		f := float64(k) * 44100 / n
+		if h := f / f0; math.Abs(h-math.Round(h))*f0 > 4*44100/n {
+			alias += power
+		}
+	}
+	return alias / total
+}
+
+func TestBandLimited(t *testing.T) {
+	for _, wave := range []int{0, 1} {
+		classic := aliasing(wave, false)
+		bl := aliasing(wave, true)
+		if bl > classic/10 {
+			t.Errorf("wave %d: band-limited aliasing %.2g, classic %.2g", wave, bl, classic)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package sfxr
+
+import "math"
+
+// resetCompressor sets up the compressor, which works like bfxr's: a power
+// curve of exponent 1/(1+4*Compression) lifts quiet samples towards full
+// scale and holds loud ones back, after which Makeup adds up to 12dB of gain.
+func (s *Synth) resetCompressor() {
+	p := &s.Params
+	s.comp_factor = 1 / (1 + 4*float64(p.Compression))
+	s.comp_gain = float32(math.Pow(10, float64(p.Makeup)*12/20))
+}
+
+// compress applies the compressor to one output sample. With both
+// parameters at zero it returns v unchanged.
+func (s *Synth) compress(v float32) float32 {
+	if s.comp_factor != 1 {
+		c := float32(math.Pow(math.Abs(float64(v)), s.comp_factor))
+		if v < 0 {
+			c = -c
+		}
+		v = c
+	}
+	return v * s.comp_gain
+}
@@ -0,0 +1,34 @@
+package sfxr
+
+import (
+	"math"
+	"testing"
+)
+
+// crest returns the peak to RMS ratio of a decaying sine rendered with the
+// given compression and makeup, along with its peak.
+func crest(compression, makeup float32) (ratio, peak float64) {
+	p := DefaultParams()
+	p.WaveType = 2
+	p.Compression = compression
+	p.Makeup = makeup
+	samples := playback(p, 20000)
+	var sum float64
+	for _, v := range samples {
+		peak = math.Max(peak, math.Abs(float64(v)))
+		sum += float64(v) * float64(v)
+	}
+	return peak / math.Sqrt(sum/float64(len(samples))), peak
+}
+
+func TestCompression(t *testing.T) {
+	dry, dryPeak := crest(0, 0)
+	wet, _ := crest(0.5, 0)
+	if wet >= dry*0.9 {
+		t.Errorf("compression left the crest factor at %.2f, uncompressed %.2f", wet, dry)
+	}
+	_, peak := crest(0, 0.5)
+	if gain := 20 * math.Log10(peak/dryPeak); math.Abs(gain-6) > 0.1 {
+		t.Errorf("makeup of 0.5 added %.2fdB, want 6dB", gain)
+	}
+}
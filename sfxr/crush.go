@@ -0,0 +1,35 @@
+package sfxr
+
+import "math"
+
+// resetCrush sets up the bit crusher. CrushBits reduces the resolution from
+// 16 bits down to 1 and CrushRate holds each sample for longer, as bfxr's
+// bit crush does; both ramps sweep them while the sound plays.
+func (s *Synth) resetCrush() {
+	p := &s.Params
+	s.crush_bits = 16 - 15*p.CrushBits
+	s.crush_bits_d = -p.CrushBitsRamp * 0.0002
+	s.crush_rate = 1 - float32(math.Pow(float64(p.CrushRate), 1.0/3.0))
+	s.crush_rate_d = -p.CrushRateRamp * 0.000015
+	s.crush_phase = 0
+	s.crush_hold = 0
+}
+
+// crush applies the bit crusher to one output sample. With both parameters
+// at zero it returns v unchanged.
+func (s *Synth) crush(v float32) float32 {
+	s.crush_rate = max(0, min(1, s.crush_rate+s.crush_rate_d))
+	s.crush_phase += s.crush_rate
+	if s.crush_phase >= 1 {
+		s.crush_phase -= 1
+		s.crush_hold = v
+	}
+	v = s.crush_hold
+
+	s.crush_bits = max(1, min(16, s.crush_bits+s.crush_bits_d))
+	if s.crush_bits < 16 {
+		steps := float32(math.Exp2(float64(s.crush_bits - 1)))
+		v = float32(math.Round(float64(v*steps))) / steps
+	}
+	return v
+}
@@ -0,0 +1,38 @@
+package sfxr
+
+import "testing"
+
+func crushed(bits, rate float32) []float32 {
+	p := DefaultParams()
+	p.WaveType = 2
+	p.CrushBits = bits
+	p.CrushRate = rate
+	return playback(p, 4000)
+}
+
+func TestCrushBits(t *testing.T) {
+	levels := make(map[float32]bool)
+	for _, v := range crushed(1, 0) {
+		levels[v] = true
+	}
+	// The sine, doubled by the phaser, is rounded to an integer in [-2, 2]
+	// before the volume is applied.
+	if len(levels) > 5 {
+		t.Errorf("1-bit crush produced %d levels", len(levels))
+	}
+	if len(levels) < 2 {
+		t.Error("1-bit crush is silent")
+	}
+}
+
+func TestCrushRate(t *testing.T) {
+	samples := crushed(0, 0.125) // holds every sample for two
+	for i := 1; i+1 < len(samples); i += 2 {
+		if samples[i] != samples[i+1] {
+			t.Fatalf("samples %d and %d differ", i, i+1)
+		}
+	}
+	if samples[1] == samples[3] {
+		t.Error("held samples never change")
+	}
+}
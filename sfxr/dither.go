@@ -0,0 +1,91 @@
+package sfxr
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Dither selects how export samples are rounded to 8, 16 or 24 bits. Float
+// exports are never dithered.
+type Dither int
+
+const (
+	// DitherNone truncates samples, as the original sfxr does.
+	DitherNone Dither = iota
+	// DitherTPDF adds triangular dither of one step before rounding, which
+	// turns quantization distortion into a constant, benign noise floor.
+	DitherTPDF
+	// DitherShaped is TPDF dither with second-order noise shaping, which
+	// moves most of the noise towards high frequencies.
+	DitherShaped
+)
+
+var ditherNames = []string{"none", "tpdf", "shaped"}
+
+func (d Dither) String() string {
+	if d < 0 || int(d) >= len(ditherNames) {
+		return "unknown"
+	}
+	return ditherNames[d]
+}
+
+// ParseDither returns the Dither with the given name ("none", "tpdf" or
+// "shaped"), ignoring case.
+func ParseDither(name string) (Dither, bool) {
+	for i, n := range ditherNames {
+		if strings.EqualFold(name, n) {
+			return Dither(i), true
+		}
+	}
+	return DitherNone, false
+}
+
+// quantizer converts export samples to PCM data. Its dither noise comes from
+// the sound's seed, so an export is reproducible.
+type quantizer struct {
+	bits   int
+	dither Dither
+	rng    *rand.Rand
+	e1, e2 float64 // last two quantization errors, for noise shaping
+}
+
+func newQuantizer(bits int, dither Dither, seed int64) *quantizer {
+	q := &quantizer{bits: bits, dither: dither}
+	if dither != DitherNone {
+		q.rng = rand.New(rand.NewSource(seed))
+	}
+	return q
+}
+
+// append appends v to data as a little-endian sample. See appendPCM.
+func (q *quantizer) append(data []byte, v float32) []byte {
+	if q.dither == DitherNone || q.bits == 32 {
+		return appendPCM(data, v, q.bits)
+	}
+
+	scale := float64(int32(1)<<(q.bits-1) - 1)
+	x := float64(v) * scale
+	if q.dither == DitherShaped {
+		// Error feedback with a noise transfer function of (1 - z^-1)^2.
+		x -= 2*q.e1 - q.e2
+	}
+	y := math.Floor(x + q.rng.Float64() - q.rng.Float64() + 0.5)
+	y = math.Max(-scale-1, math.Min(scale, y))
+	if q.dither == DitherShaped {
+		// Limit the error so a clipped sample cannot upset the feedback.
+		q.e2 = q.e1
+		q.e1 = math.Max(-2, math.Min(2, y-x))
+	}
+
+	n := int32(y)
+	switch q.bits {
+	case 8:
+		return append(data, uint8(n+128))
+	case 16:
+		return binary.LittleEndian.AppendUint16(data, uint16(n))
+	default:
+		return append(data, byte(n), byte(n>>8), byte(n>>16))
+	}
+}
@@ -0,0 +1,77 @@
+package sfxr
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+// ditherError quantizes a constant signal of 0.3 steps to 8 bits and returns
+// the mean output level, in steps, and the power of the quantization error
+// at low frequencies (a 16-sample moving average).
+func ditherError(d Dither) (mean, lowPower float64) {
+	const n = 100000
+	const level = 0.3
+	q := newQuantizer(8, d, 1)
+	var sum, window float64
+	var errs []float64
+	for i := 0; i < n; i++ {
+		y := float64(q.append(nil, level/127)[0]) - 128
+		sum += y
+		errs = append(errs, y-level)
+		window += y - level
+		if i >= 16 {
+			window -= errs[i-16]
+			lowPower += (window / 16) * (window / 16)
+		}
+	}
+	return sum / n, lowPower / (n - 16)
+}
+
+func TestDither(t *testing.T) {
+	if mean, _ := ditherError(DitherNone); mean != 0 {
+		t.Errorf("undithered mean %v, want truncation to 0", mean)
+	}
+	tpdfMean, tpdfLow := ditherError(DitherTPDF)
+	shapedMean, shapedLow := ditherError(DitherShaped)
+	for name, mean := range map[string]float64{"tpdf": tpdfMean, "shaped": shapedMean} {
+		if math.Abs(mean-0.3) > 0.02 {
+			t.Errorf("%s: mean level %v, want 0.3", name, mean)
+		}
+	}
+	if shapedLow > tpdfLow/2 {
+		t.Errorf("noise shaping left low frequency noise at %v, TPDF has %v", shapedLow, tpdfLow)
+	}
+}
+
+func TestDitherDeterministic(t *testing.T) {
+	p := loadGolden(t, "sine_attack")
+	render := func() []byte {
+		s := NewSynth(p)
+		s.Dither = DitherShaped
+		var wav bytes.Buffer
+		if err := s.WriteWAV(&wav, 22050, 8); err != nil {
+			t.Fatal(err)
+		}
+		return wav.Bytes()
+	}
+	a, b := render(), render()
+	if !bytes.Equal(a, b) {
+		t.Fatal("dithered exports differ")
+	}
+
+	s := NewSynth(p)
+	s.Dither = DitherShaped
+	pcm, err := s.PCM(22050, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(pcm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, a[wavHeaderSize:wavHeaderSize+len(data)]) {
+		t.Fatal("dithered PCM stream differs from WAV data")
+	}
+}
@@ -0,0 +1,359 @@
+package sfxr
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Category selects one of the sound presets used by the generator.
+type Category int
+
+const (
+	PickupCoin Category = iota
+	LaserShoot
+	Explosion
+	Powerup
+	HitHurt
+	Jump
+	BlipSelect
+)
+
+// Categories lists every generator category in display order.
+var Categories = []Category{PickupCoin, LaserShoot, Explosion, Powerup, HitHurt, Jump, BlipSelect}
+
+var categoryNames = []string{
+	"PICKUP/COIN",
+	"LASER/SHOOT",
+	"EXPLOSION",
+	"POWERUP",
+	"HIT/HURT",
+	"JUMP",
+	"BLIP/SELECT",
+}
+
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return "UNKNOWN"
+	}
+	return categoryNames[c]
+}
+
+// ParseCategory looks up a category by its full name ("laser/shoot") or
+// by either half of it ("laser", "shoot"), ignoring case.
+func ParseCategory(name string) (Category, bool) {
+	name = strings.ToUpper(name)
+	for _, c := range Categories {
+		if name == c.String() {
+			return c, true
+		}
+		for _, part := range strings.Split(c.String(), "/") {
+			if name == part {
+				return c, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Generator produces random sounds. Generators created with the same seed
+// produce the same sequence of sounds.
+type Generator struct {
+	rng *rand.Rand
+}
+
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (g *Generator) rnd(n int) int {
+	return g.rng.Intn(n + 1)
+}
+
+func (g *Generator) frnd(rangeVal float32) float32 {
+	return float32(g.rng.Intn(10000)) / 10000 * rangeVal
+}
+
+// newerWaves returns the source for a preset's choice of one of the newer
+// waveforms. It is seeded from the sound's own seed instead of drawing from
+// the generator, so a generator seed still produces the same sequence of
+// sounds as before those waveforms were added.
+func newerWaves(p *Params) *Generator {
+	return NewGenerator(p.Seed)
+}
+
+// Generate resets p and fills it with a random sound of category c.
+func (g *Generator) Generate(p *Params, c Category) {
+	p.Reset()
+	p.Seed = g.rng.Int63()
+	switch c {
+	case PickupCoin:
+		p.BaseFreq = 0.4 + g.frnd(0.5)
+		p.EnvAttack = 0.0
+		p.EnvSustain = g.frnd(0.1)
+		p.EnvDecay = 0.1 + g.frnd(0.4)
+		p.EnvPunch = 0.3 + g.frnd(0.3)
+		if g.rnd(1) != 0 {
+			p.ArpSpeed = 0.5 + g.frnd(0.2)
+			p.ArpMod = 0.2 + g.frnd(0.4)
+		}
+	case LaserShoot:
+		p.WaveType = g.rnd(2)
+		if p.WaveType == 2 && g.rnd(1) != 0 {
+			p.WaveType = g.rnd(1)
+		}
+		p.BaseFreq = 0.5 + g.frnd(0.5)
+		p.FreqLimit = p.BaseFreq - 0.2 - g.frnd(0.6)
+		if p.FreqLimit < 0.2 {
+			p.FreqLimit = 0.2
+		}
+		p.FreqRamp = -0.15 - g.frnd(0.2)
+		if g.rnd(2) == 0 {
+			p.BaseFreq = 0.3 + g.frnd(0.6)
+			p.FreqLimit = g.frnd(0.1)
+			p.FreqRamp = -0.35 - g.frnd(0.3)
+		}
+		if g.rnd(1) != 0 {
+			p.Duty = g.frnd(0.5)
+			p.DutyRamp = g.frnd(0.2)
+		} else {
+			p.Duty = 0.4 + g.frnd(0.5)
+			p.DutyRamp = -g.frnd(0.7)
+		}
+		p.EnvAttack = 0.0
+		p.EnvSustain = 0.1 + g.frnd(0.2)
+		p.EnvDecay = g.frnd(0.4)
+		if g.rnd(1) != 0 {
+			p.EnvPunch = g.frnd(0.3)
+		}
+		if g.rnd(2) == 0 {
+			p.PhaOffset = g.frnd(0.2)
+			p.PhaRamp = -g.frnd(0.2)
+		}
+		if g.rnd(1) != 0 {
+			p.HpfFreq = g.frnd(0.3)
+		}
+	case Explosion:
+		p.WaveType = 3
+		if g.rnd(1) != 0 {
+			p.BaseFreq = 0.1 + g.frnd(0.4)
+			p.FreqRamp = -0.1 + g.frnd(0.4)
+		} else {
+			p.BaseFreq = 0.2 + g.frnd(0.7)
+			p.FreqRamp = -0.2 - g.frnd(0.2)
+		}
+		p.BaseFreq *= p.BaseFreq
+		if g.rnd(4) == 0 {
+			p.FreqRamp = 0.0
+		}
+		if g.rnd(2) == 0 {
+			p.RepeatSpeed = 0.3 + g.frnd(0.5)
+		}
+		p.EnvAttack = 0.0
+		p.EnvSustain = 0.1 + g.frnd(0.3)
+		p.EnvDecay = g.frnd(0.5)
+		if g.rnd(1) == 0 {
+			p.PhaOffset = -0.3 + g.frnd(0.9)
+			p.PhaRamp = -g.frnd(0.3)
+		}
+		p.EnvPunch = 0.2 + g.frnd(0.6)
+		if g.rnd(1) != 0 {
+			p.VibStrength = g.frnd(0.7)
+			p.VibSpeed = g.frnd(0.6)
+		}
+		if g.rnd(2) == 0 {
+			p.ArpSpeed = 0.6 + g.frnd(0.3)
+			p.ArpMod = 0.8 - g.frnd(1.6)
+		}
+		if w := newerWaves(p); w.rnd(2) == 0 {
+			p.WaveType = 5 // pink noise rumbles more
+		} else if w.rnd(3) == 0 {
+			p.WaveType = 9 // console style noise
+		}
+	case Powerup:
+		if g.rnd(1) != 0 {
+			p.WaveType = 1
+		} else {
+			p.Duty = g.frnd(0.6)
+		}
+		if g.rnd(1) != 0 {
+			p.BaseFreq = 0.2 + g.frnd(0.3)
+			p.FreqRamp = 0.1 + g.frnd(0.4)
+			p.RepeatSpeed = 0.4 + g.frnd(0.4)
+		} else {
+			p.BaseFreq = 0.2 + g.frnd(0.3)
+			p.FreqRamp = 0.05 + g.frnd(0.2)
+			if g.rnd(1) != 0 {
+				p.VibStrength = g.frnd(0.7)
+				p.VibSpeed = g.frnd(0.6)
+			}
+		}
+		p.EnvAttack = 0.0
+		p.EnvSustain = g.frnd(0.4)
+		p.EnvDecay = 0.1 + g.frnd(0.4)
+	case HitHurt:
+		p.WaveType = g.rnd(2)
+		if p.WaveType == 2 {
+			p.WaveType = 3
+		}
+		if p.WaveType == 0 {
+			p.Duty = g.frnd(0.6)
+		}
+		p.BaseFreq = 0.2 + g.frnd(0.6)
+		p.FreqRamp = -0.3 - g.frnd(0.4)
+		p.EnvAttack = 0.0
+		p.EnvSustain = g.frnd(0.1)
+		p.EnvDecay = 0.1 + g.frnd(0.2)
+		if g.rnd(1) != 0 {
+			p.HpfFreq = g.frnd(0.3)
+		}
+		if p.WaveType != 3 && newerWaves(p).rnd(3) == 0 {
+			p.WaveType = 4 // triangle
+		}
+	case Jump:
+		p.WaveType = 0
+		p.Duty = g.frnd(0.6)
+		p.BaseFreq = 0.3 + g.frnd(0.3)
+		p.FreqRamp = 0.1 + g.frnd(0.2)
+		p.EnvAttack = 0.0
+		p.EnvSustain = 0.1 + g.frnd(0.3)
+		p.EnvDecay = 0.1 + g.frnd(0.2)
+		if g.rnd(1) != 0 {
+			p.HpfFreq = g.frnd(0.3)
+		}
+		if g.rnd(1) != 0 {
+			p.LpfFreq = 1.0 - g.frnd(0.6)
+		}
+		if newerWaves(p).rnd(2) == 0 {
+			p.WaveType = 4 // triangle
+		}
+	case BlipSelect:
+		p.WaveType = g.rnd(1)
+		if p.WaveType == 0 {
+			p.Duty = g.frnd(0.6)
+		}
+		p.BaseFreq = 0.2 + g.frnd(0.4)
+		p.EnvAttack = 0.0
+		p.EnvSustain = 0.1 + g.frnd(0.1)
+		p.EnvDecay = g.frnd(0.2)
+		p.HpfFreq = 0.1
+	}
+	p.Sanitize()
+}
+
+// Randomize fills p with a completely random sound. Like the other generator
+// functions, it leaves every parameter within its legal range.
+func (g *Generator) Randomize(p *Params) {
+	p.Seed = g.rng.Int63()
+	p.BaseFreq = float32(math.Pow(float64(g.frnd(2.0)-1.0), 2.0))
+	if g.rnd(1) != 0 {
+		p.BaseFreq = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0)) + 0.5
+	}
+	p.FreqLimit = 0.0
+	p.FreqRamp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 5.0))
+	if p.BaseFreq > 0.7 && p.FreqRamp > 0.2 {
+		p.FreqRamp = -p.FreqRamp
+	}
+	if p.BaseFreq < 0.2 && p.FreqRamp < -0.05 {
+		p.FreqRamp = -p.FreqRamp
+	}
+	p.FreqDramp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.Duty = g.frnd(2.0) - 1.0
+	p.DutyRamp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.VibStrength = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.VibSpeed = g.frnd(2.0) - 1.0
+	p.VibDelay = g.frnd(2.0) - 1.0
+	p.EnvAttack = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.EnvSustain = float32(math.Pow(float64(g.frnd(2.0)-1.0), 2.0))
+	p.EnvDecay = g.frnd(2.0) - 1.0
+	p.EnvPunch = float32(math.Pow(float64(g.frnd(0.8)), 2.0))
+	if p.EnvAttack+p.EnvSustain+p.EnvDecay < 0.2 {
+		p.EnvSustain += 0.2 + g.frnd(0.3)
+		p.EnvDecay += 0.2 + g.frnd(0.3)
+	}
+	p.LpfResonance = g.frnd(2.0) - 1.0
+	p.LpfFreq = 1.0 - float32(math.Pow(float64(g.frnd(1.0)), 3.0))
+	p.LpfRamp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	if p.LpfFreq < 0.1 && p.LpfRamp < -0.05 {
+		p.LpfRamp = -p.LpfRamp
+	}
+	p.HpfFreq = float32(math.Pow(float64(g.frnd(1.0)), 5.0))
+	p.HpfRamp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 5.0))
+	p.PhaOffset = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.PhaRamp = float32(math.Pow(float64(g.frnd(2.0)-1.0), 3.0))
+	p.RepeatSpeed = g.frnd(2.0) - 1.0
+	p.ArpSpeed = g.frnd(2.0) - 1.0
+	p.ArpMod = g.frnd(2.0) - 1.0
+	p.Sanitize()
+}
+
+// Mutate nudges some of the parameters of p by a small random amount.
+func (g *Generator) Mutate(p *Params) {
+	if g.rnd(1) != 0 {
+		p.BaseFreq += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.FreqRamp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.FreqDramp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.Duty += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.DutyRamp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.VibStrength += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.VibSpeed += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.VibDelay += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.EnvAttack += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.EnvSustain += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.EnvDecay += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.EnvPunch += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.LpfResonance += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.LpfFreq += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.LpfRamp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.HpfFreq += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.HpfRamp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.PhaOffset += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.PhaRamp += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.RepeatSpeed += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.ArpSpeed += g.frnd(0.1) - 0.05
+	}
+	if g.rnd(1) != 0 {
+		p.ArpMod += g.frnd(0.1) - 0.05
+	}
+	p.Sanitize()
+}
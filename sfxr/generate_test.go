@@ -0,0 +1,37 @@
+package sfxr
+
+import "testing"
+
+// TestGenerateSequence pins the sounds a generator seed produced before the
+// newer waveforms were added. Presets may pick one of those waveforms, but
+// everything else, including the sounds that follow, must stay the same.
+func TestGenerateSequence(t *testing.T) {
+	want := []struct {
+		seed     int64
+		baseFreq float32
+	}{
+		{3440579354231278675, 0.64935},
+		{3532963341805492868, 0.59645},
+		{2643318057788968173, 0.1308703},
+		{1398083032764859219, 0.48161},
+		{7697922259999977824, 0.37514},
+		{2157255887366150544, 0.46203},
+		{559346544157562657, 0.33984},
+		{7994582440685163287, 0.8783},
+		{4988602387584303978, 0.81515},
+		{2521561481059334249, 0.44112182},
+		{8482975297643168000, 0.2324},
+		{2316217535716785625, 0.76262},
+		{8331124630324891162, 0.32349002},
+		{4441891809806786300, 0.30456},
+	}
+	g := NewGenerator(42)
+	for i, w := range want {
+		c := Categories[i%len(Categories)]
+		p := DefaultParams()
+		g.Generate(&p, c)
+		if p.Seed != w.seed || p.BaseFreq != w.baseFreq {
+			t.Errorf("sound %d (%v): seed %d, base_freq %v, want %d, %v", i, c, p.Seed, p.BaseFreq, w.seed, w.baseFreq)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package sfxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The golden files in testdata/golden are the WAV renders of the .cfg files
+// next to them. After a deliberate change to the sound, regenerate them with
+//
+//	go test ./sfxr -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden WAV files in testdata/golden")
+
+// goldenBits lists the sounds rendered at 8 bits; the rest use 16.
+var goldenBits = map[string]int{
+	"square_8bit": 8,
+}
+
+// goldenTolerance is the largest per-sample difference, in quantization
+// steps, that is not reported. It absorbs floating point differences between
+// platforms, e.g. fused multiply-add on arm64.
+const goldenTolerance = 2
+
+const wavHeaderSize = 44
+
+func renderGolden(t *testing.T, cfg string, bits int) []byte {
+	t.Helper()
+	p := DefaultParams()
+	warnings, err := p.LoadSettings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	out := filepath.Join(t.TempDir(), "out.wav")
+	if err := NewSynth(p).ExportWAV(out, 44100, bits); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func pcmSamples(data []byte, bits int) []int {
+	size := binary.LittleEndian.Uint32(data[wavHeaderSize-4:])
+	data = data[wavHeaderSize : wavHeaderSize+int(size)]
+	var samples []int
+	if bits == 16 {
+		for i := 0; i+1 < len(data); i += 2 {
+			samples = append(samples, int(int16(binary.LittleEndian.Uint16(data[i:]))))
+		}
+	} else {
+		for _, b := range data {
+			samples = append(samples, int(b))
+		}
+	}
+	return samples
+}
+
+func TestGolden(t *testing.T) {
+	cfgs, err := filepath.Glob("testdata/golden/*.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) == 0 {
+		t.Fatal("no golden sounds found")
+	}
+	for _, cfg := range cfgs {
+		name := strings.TrimSuffix(filepath.Base(cfg), ".cfg")
+		t.Run(name, func(t *testing.T) {
+			bits := goldenBits[name]
+			if bits == 0 {
+				bits = 16
+			}
+			got := renderGolden(t, cfg, bits)
+			golden := strings.TrimSuffix(cfg, ".cfg") + ".wav"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got[:wavHeaderSize], want[:wavHeaderSize]) {
+				t.Fatalf("WAV header differs:\ngot  % x\nwant % x", got[:wavHeaderSize], want[:wavHeaderSize])
+			}
+			gs, ws := pcmSamples(got, bits), pcmSamples(want, bits)
+			if len(gs) != len(ws) {
+				t.Fatalf("rendered %d samples, want %d", len(gs), len(ws))
+			}
+			bad, first := 0, -1
+			for i := range gs {
+				d := gs[i] - ws[i]
+				if d < -goldenTolerance || d > goldenTolerance {
+					if first < 0 {
+						first = i
+					}
+					bad++
+				}
+			}
+			if bad > 0 {
+				t.Errorf("%d of %d samples differ by more than %d; first at %d: got %d, want %d",
+					bad, len(gs), goldenTolerance, first, gs[first], ws[first])
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package sfxr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// jsfxr (and sfxr.me) share sounds either as a JSON object or as a base58
+// string of the wave type followed by 22 little-endian float32 parameters.
+
+const b58alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+type jsfxrParams struct {
+	OldParams bool `json:"oldParams"`
+	WaveType  int  `json:"wave_type"`
+
+	EnvAttack  float32 `json:"p_env_attack"`
+	EnvSustain float32 `json:"p_env_sustain"`
+	EnvPunch   float32 `json:"p_env_punch"`
+	EnvDecay   float32 `json:"p_env_decay"`
+
+	BaseFreq  float32 `json:"p_base_freq"`
+	FreqLimit float32 `json:"p_freq_limit"`
+	FreqRamp  float32 `json:"p_freq_ramp"`
+	FreqDramp float32 `json:"p_freq_dramp"`
+
+	VibStrength float32 `json:"p_vib_strength"`
+	VibSpeed    float32 `json:"p_vib_speed"`
+
+	ArpMod   float32 `json:"p_arp_mod"`
+	ArpSpeed float32 `json:"p_arp_speed"`
+
+	Duty     float32 `json:"p_duty"`
+	DutyRamp float32 `json:"p_duty_ramp"`
+
+	RepeatSpeed float32 `json:"p_repeat_speed"`
+
+	PhaOffset float32 `json:"p_pha_offset"`
+	PhaRamp   float32 `json:"p_pha_ramp"`
+
+	LpfFreq      float32 `json:"p_lpf_freq"`
+	LpfRamp      float32 `json:"p_lpf_ramp"`
+	LpfResonance float32 `json:"p_lpf_resonance"`
+
+	HpfFreq float32 `json:"p_hpf_freq"`
+	HpfRamp float32 `json:"p_hpf_ramp"`
+
+	SoundVol   float32 `json:"sound_vol"`
+	SampleRate int     `json:"sample_rate"`
+	SampleSize int     `json:"sample_size"`
+}
+
+// floats returns pointers to the float parameters in jsfxr's base58 order.
+func (j *jsfxrParams) floats() []*float32 {
+	return []*float32{
+		&j.EnvAttack, &j.EnvSustain, &j.EnvPunch, &j.EnvDecay,
+		&j.BaseFreq, &j.FreqLimit, &j.FreqRamp, &j.FreqDramp,
+		&j.VibStrength, &j.VibSpeed,
+		&j.ArpMod, &j.ArpSpeed,
+		&j.Duty, &j.DutyRamp,
+		&j.RepeatSpeed,
+		&j.PhaOffset, &j.PhaRamp,
+		&j.LpfFreq, &j.LpfRamp, &j.LpfResonance,
+		&j.HpfFreq, &j.HpfRamp,
+	}
+}
+
+// jsfxrWaveFallback picks the closest jsfxr waveform for the ones it lacks.
+var jsfxrWaveFallback = map[int]int{4: 2, 5: 3, 6: 0, 7: 2, 8: 2, 9: 3, 10: 3}
+
+// jsfxrUnsupported lists the float parameters that jsfxr lacks. Each is
+// reported as dropped when it would have changed the sound.
+var jsfxrUnsupported = []string{
+	"crush_bits",
+	"crush_bits_ramp",
+	"crush_rate",
+	"crush_rate_ramp",
+	"compression",
+	"makeup",
+}
+
+// jsfxrWarnings returns a warning for each part of p that a jsfxr export
+// cannot hold.
+func (p *Params) jsfxrWarnings() []string {
+	var warnings []string
+	if fallback, ok := jsfxrWaveFallback[p.WaveType]; ok {
+		warnings = append(warnings, fmt.Sprintf("jsfxr has no %s waveform, exported as %s",
+			waveNames[p.WaveType], waveNames[fallback]))
+	}
+	if p.BandLimited {
+		warnings = append(warnings, "jsfxr has no band_limited, dropped")
+	}
+	for _, f := range p.fields() {
+		if *f.value != 0 && slices.Contains(jsfxrUnsupported, f.name) {
+			warnings = append(warnings, fmt.Sprintf("jsfxr has no %s, dropped", f.name))
+		}
+	}
+	return warnings
+}
+
+func newJsfxrParams(p *Params) jsfxrParams {
+	waveType := p.WaveType
+	if fallback, ok := jsfxrWaveFallback[waveType]; ok {
+		waveType = fallback
+	}
+	return jsfxrParams{
+		OldParams:    true,
+		WaveType:     waveType,
+		EnvAttack:    p.EnvAttack,
+		EnvSustain:   p.EnvSustain,
+		EnvPunch:     p.EnvPunch,
+		EnvDecay:     p.EnvDecay,
+		BaseFreq:     p.BaseFreq,
+		FreqLimit:    p.FreqLimit,
+		FreqRamp:     p.FreqRamp,
+		FreqDramp:    p.FreqDramp,
+		VibStrength:  p.VibStrength,
+		VibSpeed:     p.VibSpeed,
+		ArpMod:       p.ArpMod,
+		ArpSpeed:     p.ArpSpeed,
+		Duty:         p.Duty,
+		DutyRamp:     p.DutyRamp,
+		RepeatSpeed:  p.RepeatSpeed,
+		PhaOffset:    p.PhaOffset,
+		PhaRamp:      p.PhaRamp,
+		LpfFreq:      p.LpfFreq,
+		LpfRamp:      p.LpfRamp,
+		LpfResonance: p.LpfResonance,
+		HpfFreq:      p.HpfFreq,
+		HpfRamp:      p.HpfRamp,
+		SoundVol:     p.SoundVol,
+		SampleRate:   44100,
+		SampleSize:   8,
+	}
+}
+
+func (j *jsfxrParams) params() Params {
+	p := DefaultParams()
+	p.WaveType = j.WaveType
+	p.EnvAttack = j.EnvAttack
+	p.EnvSustain = j.EnvSustain
+	p.EnvPunch = j.EnvPunch
+	p.EnvDecay = j.EnvDecay
+	p.BaseFreq = j.BaseFreq
+	p.FreqLimit = j.FreqLimit
+	p.FreqRamp = j.FreqRamp
+	p.FreqDramp = j.FreqDramp
+	p.VibStrength = j.VibStrength
+	p.VibSpeed = j.VibSpeed
+	p.ArpMod = j.ArpMod
+	p.ArpSpeed = j.ArpSpeed
+	p.Duty = j.Duty
+	p.DutyRamp = j.DutyRamp
+	p.RepeatSpeed = j.RepeatSpeed
+	p.PhaOffset = j.PhaOffset
+	p.PhaRamp = j.PhaRamp
+	p.LpfFreq = j.LpfFreq
+	p.LpfRamp = j.LpfRamp
+	p.LpfResonance = j.LpfResonance
+	p.HpfFreq = j.HpfFreq
+	p.HpfRamp = j.HpfRamp
+	p.SoundVol = j.SoundVol
+	return p
+}
+
+// isJsfxrJSON reports whether a JSON object uses jsfxr's field names.
+func isJsfxrJSON(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return false
+	}
+	_, ok := fields["p_base_freq"]
+	return ok
+}
+
+func (p *Params) loadJsfxrJSON(data []byte) error {
+	q := DefaultParams()
+	j := newJsfxrParams(&q)
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("invalid jsfxr JSON: %w", err)
+	}
+	*p = j.params()
+	return nil
+}
+
+func (p *Params) loadJsfxrB58(s string) error {
+	data := b58decode(s)
+	if data == nil {
+		return errors.New("not a jsfxr share string")
+	}
+	if len(data) != 1+22*4 {
+		return fmt.Errorf("jsfxr share string holds %d bytes, want %d", len(data), 1+22*4)
+	}
+	q := DefaultParams()
+	j := newJsfxrParams(&q)
+	j.WaveType = int(data[0])
+	for i, f := range j.floats() {
+		*f = math.Float32frombits(binary.LittleEndian.Uint32(data[1+i*4:]))
+	}
+	*p = j.params()
+	return nil
+}
+
+// ImportJsfxr replaces p with a sound shared by jsfxr or sfxr.me. It accepts
+// a base58 share string, an sfxr.me URL ending in one, or a jsfxr JSON object.
+// Vibrato delay and the noise seed, which jsfxr lacks, are reset. Values out
+// of range are clamped with a warning, as in LoadSettings.
+func (p *Params) ImportJsfxr(s string) (warnings []string, err error) {
+	s = strings.TrimSpace(s)
+	if isJSON([]byte(s)) {
+		if !isJsfxrJSON([]byte(s)) {
+			return nil, errors.New("JSON object is not a jsfxr sound")
+		}
+		err = p.loadJsfxrJSON([]byte(s))
+	} else {
+		if i := strings.LastIndexByte(s, '#'); i >= 0 {
+			s = s[i+1:]
+		}
+		err = p.loadJsfxrB58(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Sanitize(), nil
+}
+
+// JsfxrB58 returns the jsfxr base58 share string for p. jsfxr only has the
+// classic waveforms and parameters; a warning is returned for each feature
+// of p that had to be replaced or dropped.
+func (p *Params) JsfxrB58() (share string, warnings []string) {
+	j := newJsfxrParams(p)
+	data := make([]byte, 1, 1+22*4)
+	data[0] = byte(j.WaveType)
+	for _, f := range j.floats() {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(*f))
+	}
+	return b58encode(data), p.jsfxrWarnings()
+}
+
+// JsfxrJSON returns p as a jsfxr JSON object, with warnings as for JsfxrB58.
+func (p *Params) JsfxrJSON() (object string, warnings []string) {
+	data, _ := json.Marshal(newJsfxrParams(p))
+	return string(data), p.jsfxrWarnings()
+}
+
+func b58encode(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		sb.WriteByte(b58alphabet[0])
+	}
+	n := new(big.Int).SetBytes(data)
+	base := big.NewInt(58)
+	mod := new(big.Int)
+	var digits []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		digits = append(digits, b58alphabet[mod.Int64()])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		sb.WriteByte(digits[i])
+	}
+	return sb.String()
+}
+
+// b58decode returns nil if s is not valid base58 or is far longer than any
+// share string.
+func b58decode(s string) []byte {
+	if len(s) > 256 {
+		return nil
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == b58alphabet[0] {
+		zeros++
+	}
+	n := new(big.Int)
+	base := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		c := strings.IndexByte(b58alphabet, s[i])
+		if c < 0 {
+			return nil
+		}
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(c)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...)
+}
@@ -0,0 +1,129 @@
+package sfxr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJsfxrExportWaveFallback(t *testing.T) {
+	for waveType := 0; waveType < waveTypeCount; waveType++ {
+		p := DefaultParams()
+		p.WaveType = waveType
+		share, warnings := p.JsfxrB58()
+		_, jsonWarnings := p.JsfxrJSON()
+		var q Params
+		if _, err := q.ImportJsfxr(share); err != nil {
+			t.Fatal(err)
+		}
+		want, replaced := jsfxrWaveFallback[waveType]
+		if !replaced {
+			want = waveType
+		}
+		if q.WaveType != want {
+			t.Errorf("%s exported as wave_type %d, want %d", waveNames[waveType], q.WaveType, want)
+		}
+		if replaced != (len(warnings) == 1) || len(warnings) != len(jsonWarnings) {
+			t.Errorf("%s: warnings %q and %q", waveNames[waveType], warnings, jsonWarnings)
+		}
+	}
+}
+
+func TestJsfxrExportDropped(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  func(*Params)
+	}{
+		{"band_limited", func(p *Params) { p.BandLimited = true }},
+		{"crush_bits", func(p *Params) { p.CrushBits = 0.5 }},
+		{"crush_bits_ramp", func(p *Params) { p.CrushBitsRamp = -0.5 }},
+		{"crush_rate", func(p *Params) { p.CrushRate = 0.5 }},
+		{"crush_rate_ramp", func(p *Params) { p.CrushRateRamp = 0.5 }},
+		{"compression", func(p *Params) { p.Compression = 0.5 }},
+		{"makeup", func(p *Params) { p.Makeup = 0.5 }},
+	} {
+		p := DefaultParams()
+		tt.set(&p)
+		_, warnings := p.JsfxrB58()
+		if len(warnings) != 1 || !strings.Contains(warnings[0], tt.name) {
+			t.Errorf("%s: warnings %q", tt.name, warnings)
+		}
+	}
+}
+
+// coinShare is a jsfxr share string computed independently of this package
+// from jsfxr's format: the wave type byte followed by 22 little-endian
+// float32 parameters, in base58. The square wave and zero attack time make
+// it start with five zero bytes.
+const coinShare = "11111C2PQVd77GnnvFYmbuxsdjNVb9tV7Rkwv3Rg5G1Ubit2JGW89ewEMPYL4eLnG9Y5AAzgXqtxcvcUwNEYQp8hQvdqEWWdC6oXhm8Ht67zTe6sy66whDrw"
+
+func coinParams() Params {
+	p := DefaultParams()
+	p.WaveType = 0
+	p.EnvAttack = 0
+	p.EnvSustain = 0.3
+	p.EnvPunch = 0.5
+	p.EnvDecay = 0.4
+	p.BaseFreq = 0.5
+	p.ArpMod = 0.4
+	p.ArpSpeed = 0.6
+	p.LpfFreq = 1
+	p.HpfFreq = 0.1
+	return p
+}
+
+func TestImportJsfxrShare(t *testing.T) {
+	for _, s := range []string{coinShare, "https://sfxr.me/#" + coinShare} {
+		var p Params
+		warnings, err := p.ImportJsfxr(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) > 0 {
+			t.Errorf("unexpected warnings: %v", warnings)
+		}
+		if want := coinParams(); p != want {
+			t.Errorf("imported %+v\nwant %+v", p, want)
+		}
+	}
+
+	p := coinParams()
+	if share, _ := p.JsfxrB58(); share != coinShare {
+		t.Errorf("share string %s, want %s", share, coinShare)
+	}
+}
+
+func TestJsfxrRoundTrip(t *testing.T) {
+	g := NewGenerator(1)
+	for _, c := range Categories {
+		p := DefaultParams()
+		g.Generate(&p, c)
+		if fallback, ok := jsfxrWaveFallback[p.WaveType]; ok {
+			p.WaveType = fallback
+		}
+		// jsfxr has no vibrato delay or noise seed.
+		p.VibDelay = 0
+		p.Seed = 0
+
+		share, _ := p.JsfxrB58()
+		object, _ := p.JsfxrJSON()
+		for _, s := range []string{share, object} {
+			var q Params
+			if _, err := q.ImportJsfxr(s); err != nil {
+				t.Fatalf("%v: %v", c, err)
+			}
+			if q != p {
+				t.Errorf("%v: round trip of %s gave %+v\nwant %+v", c, s, q, p)
+			}
+		}
+	}
+}
+
+func TestB58LeadingZeros(t *testing.T) {
+	for _, data := range [][]byte{{0}, {0, 0, 1}, {0, 0xff, 0}, {3, 0, 0}} {
+		s := b58encode(data)
+		if got := b58decode(s); !bytes.Equal(got, data) {
+			t.Errorf("%x encoded as %q decodes to %x", data, s, got)
+		}
+	}
+}
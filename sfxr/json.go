@@ -0,0 +1,42 @@
+package sfxr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// isJSON reports whether data looks like a JSON object rather than a binary
+// settings file. Binary files start with a little-endian version number, so
+// their first byte is never '{'.
+func isJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '{'
+}
+
+// loadJSON reads parameters from a JSON object in either our own or jsfxr's
+// format. Fields missing from the object take their default values.
+func (p *Params) loadJSON(data []byte) error {
+	if isJsfxrJSON(data) {
+		return p.loadJsfxrJSON(data)
+	}
+	q := DefaultParams()
+	if err := json.Unmarshal(data, &q); err != nil {
+		return fmt.Errorf("invalid JSON settings: %w", err)
+	}
+	*p = q
+	return nil
+}
+
+func (p *Params) saveJSON(filename string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return replaceFile(filename, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
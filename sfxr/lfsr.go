@@ -0,0 +1,33 @@
+package sfxr
+
+// The NES and Game Boy make noise with a 15-bit linear-feedback shift
+// register. Long mode feeds back bits 0 and 1, for a sequence of 32767
+// steps; short mode feeds back bits 0 and 6, which repeats after 93 steps
+// and sounds metallic.
+const (
+	lfsrLongTap  = 1
+	lfsrShortTap = 6
+)
+
+// stepLFSR clocks the shift register once and returns its output bit.
+func (s *Synth) stepLFSR(tap int) uint16 {
+	bit := (s.lfsr ^ s.lfsr>>tap) & 1
+	s.lfsr = s.lfsr>>1 | bit<<14
+	return s.lfsr & 1
+}
+
+// fillLFSR refills the noise buffer from the shift register, one step per
+// entry, so the noise follows the period just like white noise does.
+func (s *Synth) fillLFSR(short bool) {
+	tap := lfsrLongTap
+	if short {
+		tap = lfsrShortTap
+	}
+	for i := range s.noise_buffer {
+		if s.stepLFSR(tap) == 0 {
+			s.noise_buffer[i] = 0.5
+		} else {
+			s.noise_buffer[i] = -0.5
+		}
+	}
+}
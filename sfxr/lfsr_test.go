@@ -0,0 +1,26 @@
+package sfxr
+
+import "testing"
+
+func TestLFSRPeriod(t *testing.T) {
+	for _, tt := range []struct {
+		tap    int
+		period int
+	}{
+		{lfsrLongTap, 32767},
+		{lfsrShortTap, 93},
+	} {
+		s := &Synth{lfsr: 1}
+		n := 0
+		for {
+			s.stepLFSR(tt.tap)
+			n++
+			if s.lfsr == 1 || n > 1<<15 {
+				break
+			}
+		}
+		if n != tt.period {
+			t.Errorf("tap %d: period %d, want %d", tt.tap, n, tt.period)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package sfxr
+
+import (
+	"math"
+	"strings"
+)
+
+// Levels describes how loud a sound is. Levels of silence are -Inf.
+type Levels struct {
+	Peak     float64 // sample peak in dBFS
+	RMS      float64 // RMS level in dBFS
+	Loudness float64 // integrated loudness in LUFS, per ITU-R BS.1770
+}
+
+// Normalization selects how exports are scaled to a target level.
+type Normalization int
+
+const (
+	// NormalizeOff leaves the level to the sound's volume and Synth.Gain.
+	NormalizeOff Normalization = iota
+	// NormalizePeak scales the sound so its peak is at the target in dBFS.
+	NormalizePeak
+	// NormalizeLoudness scales the sound so its integrated loudness is at
+	// the target in LUFS. Loud targets can clip.
+	NormalizeLoudness
+)
+
+var normalizationNames = []string{"off", "peak", "loudness"}
+
+func (n Normalization) String() string {
+	if n < 0 || int(n) >= len(normalizationNames) {
+		return "unknown"
+	}
+	return normalizationNames[n]
+}
+
+// ParseNormalization returns the Normalization with the given name ("off",
+// "peak" or "loudness"), ignoring case.
+func ParseNormalization(name string) (Normalization, bool) {
+	for i, n := range normalizationNames {
+		if strings.EqualFold(name, n) {
+			return Normalization(i), true
+		}
+	}
+	return NormalizeOff, false
+}
+
+// normalize scales samples, rendered at freq, to s.NormalizeTarget.
+func (s *Synth) normalize(samples []float32, freq int) {
+	var level float64
+	switch s.Normalize {
+	case NormalizePeak:
+		level = MeasureLevels(samples, freq).Peak
+	case NormalizeLoudness:
+		level = MeasureLevels(samples, freq).Loudness
+	default:
+		return
+	}
+	if math.IsInf(level, -1) {
+		return // silence stays silent
+	}
+	gain := float32(math.Pow(10, (s.NormalizeTarget-level)/20))
+	for i := range samples {
+		samples[i] *= gain
+	}
+}
+
+func decibels(power float64) float64 {
+	return 10 * math.Log10(power)
+}
+
+// MeasureLevels measures samples rendered at freq. Loudness is gated as in
+// BS.1770 over 400ms blocks; a sound shorter than one block is measured as
+// a single block.
+func MeasureLevels(samples []float32, freq int) Levels {
+	var peak, sum float64
+	for _, v := range samples {
+		x := float64(v)
+		peak = math.Max(peak, math.Abs(x))
+		sum += x * x
+	}
+	levels := Levels{
+		Peak:     decibels(peak * peak),
+		RMS:      math.Inf(-1),
+		Loudness: math.Inf(-1),
+	}
+	if len(samples) > 0 {
+		levels.RMS = decibels(sum / float64(len(samples)))
+		levels.Loudness = loudness(samples, freq)
+	}
+	return levels
+}
+
+// biquad is a second order IIR filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) filter(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stages of the BS.1770 K-weighting filter, a
+// high shelf followed by a high pass, designed for sample rate freq. At
+// 48kHz they match the coefficients given in the standard.
+func kWeighting(freq int) (shelf, highpass biquad) {
+	k := math.Tan(math.Pi * 1681.974450955533 / float64(freq))
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / float64(freq))
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highpass = biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
+
+// loudness returns the gated integrated loudness of samples in LUFS.
+func loudness(samples []float32, freq int) float64 {
+	shelf, highpass := kWeighting(freq)
+	weighted := make([]float64, len(samples))
+	for i, v := range samples {
+		x := highpass.filter(shelf.filter(float64(v)))
+		weighted[i] = x * x
+	}
+
+	// Mean square of 400ms blocks overlapping by 75%.
+	block := freq * 4 / 10
+	step := block / 4
+	if block > len(weighted) {
+		block, step = len(weighted), len(weighted)
+	}
+	var blocks []float64
+	for start := 0; start+block <= len(weighted); start += step {
+		var sum float64
+		for _, x := range weighted[start : start+block] {
+			sum += x
+		}
+		blocks = append(blocks, sum/float64(block))
+	}
+
+	lufs := func(power float64) float64 { return -0.691 + decibels(power) }
+	gated := func(threshold float64) float64 {
+		var sum float64
+		n := 0
+		for _, z := range blocks {
+			if lufs(z) > threshold {
+				sum += z
+				n++
+			}
+		}
+		if n == 0 {
+			return math.Inf(-1)
+		}
+		return lufs(sum / float64(n))
+	}
+	ungated := gated(-70)
+	if math.IsInf(ungated, -1) {
+		return ungated
+	}
+	return gated(ungated - 10)
+}
@@ -0,0 +1,76 @@
+package sfxr
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestMeasureLevels(t *testing.T) {
+	// A 997 Hz sine with a peak of -20 dBFS measures -23 LUFS in mono.
+	for _, freq := range []int{44100, 48000} {
+		samples := make([]float32, freq*2)
+		for i := range samples {
+			samples[i] = float32(0.1 * math.Sin(2*math.Pi*997*float64(i)/float64(freq)))
+		}
+		levels := MeasureLevels(samples, freq)
+		for _, c := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"peak", levels.Peak, -20},
+			{"RMS", levels.RMS, -23.01},
+			{"loudness", levels.Loudness, -23.01},
+		} {
+			if math.Abs(c.got-c.want) > 0.05 {
+				t.Errorf("%d Hz: %s %.2f, want %.2f", freq, c.name, c.got, c.want)
+			}
+		}
+	}
+
+	levels := MeasureLevels(make([]float32, 1000), 44100)
+	if !math.IsInf(levels.Peak, -1) || !math.IsInf(levels.Loudness, -1) {
+		t.Errorf("silence measured as %+v", levels)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	p := loadGolden(t, "sine_vibrato")
+	for _, c := range []struct {
+		mode   Normalization
+		target float64
+	}{
+		{NormalizePeak, -1},
+		{NormalizePeak, -12},
+		{NormalizeLoudness, -23},
+	} {
+		s := NewSynth(p)
+		s.Normalize = c.mode
+		s.NormalizeTarget = c.target
+		var wav bytes.Buffer
+		if err := s.WriteWAV(&wav, 48000, 32); err != nil {
+			t.Fatal(err)
+		}
+		levels := s.Report().Levels
+		got := levels.Peak
+		if c.mode == NormalizeLoudness {
+			got = levels.Loudness
+		}
+		if math.Abs(got-c.target) > 0.01 {
+			t.Errorf("%v normalization to %v: measured %+v", c.mode, c.target, levels)
+		}
+
+		pcm, err := s.PCM(48000, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(pcm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, wav.Bytes()[len(wav.Bytes())-len(data):]) {
+			t.Errorf("%v normalization: PCM stream differs from WAV data", c.mode)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sfxr
+
+// Params holds the user-facing parameters of a single sound.
+type Params struct {
+	// WaveType selects the oscillator: 0 square, 1 sawtooth, 2 sine,
+	// 3 noise, 4 triangle, 5 pink noise, 6 tan, 7 whistle, 8 breaker, or
+	// NES style 1-bit noise, 9 long or 10 short.
+	WaveType int `json:"wave_type"`
+
+	BaseFreq  float32 `json:"base_freq"`
+	FreqLimit float32 `json:"freq_limit"`
+	FreqRamp  float32 `json:"freq_ramp"`
+	FreqDramp float32 `json:"freq_dramp"`
+	Duty      float32 `json:"duty"`
+	DutyRamp  float32 `json:"duty_ramp"`
+
+	VibStrength float32 `json:"vib_strength"`
+	VibSpeed    float32 `json:"vib_speed"`
+	VibDelay    float32 `json:"vib_delay"`
+
+	EnvAttack  float32 `json:"env_attack"`
+	EnvSustain float32 `json:"env_sustain"`
+	EnvDecay   float32 `json:"env_decay"`
+	EnvPunch   float32 `json:"env_punch"`
+
+	FilterOn     bool    `json:"filter_on"`
+	LpfResonance float32 `json:"lpf_resonance"`
+	LpfFreq      float32 `json:"lpf_freq"`
+	LpfRamp      float32 `json:"lpf_ramp"`
+	HpfFreq      float32 `json:"hpf_freq"`
+	HpfRamp      float32 `json:"hpf_ramp"`
+
+	PhaOffset float32 `json:"pha_offset"`
+	PhaRamp   float32 `json:"pha_ramp"`
+
+	RepeatSpeed float32 `json:"repeat_speed"`
+
+	ArpSpeed float32 `json:"arp_speed"`
+	ArpMod   float32 `json:"arp_mod"`
+
+	CrushBits     float32 `json:"crush_bits"`
+	CrushBitsRamp float32 `json:"crush_bits_ramp"`
+	CrushRate     float32 `json:"crush_rate"`
+	CrushRateRamp float32 `json:"crush_rate_ramp"`
+
+	Compression float32 `json:"compression"`
+	Makeup      float32 `json:"makeup"`
+
+	SoundVol float32 `json:"sound_vol"`
+
+	// BandLimited renders the square and sawtooth waves without aliasing,
+	// at the cost of sounding slightly different from the original sfxr.
+	BandLimited bool `json:"band_limited"`
+
+	// Seed drives the noise generator, so a sound renders identically
+	// every time it is played or exported.
+	Seed int64 `json:"seed"`
+}
+
+// DefaultParams returns the parameters of the default blip sound.
+func DefaultParams() Params {
+	p := Params{SoundVol: 0.5}
+	p.Reset()
+	return p
+}
+
+// Reset restores every synthesis parameter to its default value. SoundVol is
+// left untouched, as it is a playback setting rather than part of the sound.
+func (p *Params) Reset() {
+	p.WaveType = 0
+
+	p.BaseFreq = 0.3
+	p.FreqLimit = 0.0
+	p.FreqRamp = 0.0
+	p.FreqDramp = 0.0
+	p.Duty = 0.0
+	p.DutyRamp = 0.0
+
+	p.VibStrength = 0.0
+	p.VibSpeed = 0.0
+	p.VibDelay = 0.0
+
+	p.EnvAttack = 0.0
+	p.EnvSustain = 0.3
+	p.EnvDecay = 0.4
+	p.EnvPunch = 0.0
+
+	p.FilterOn = false
+	p.LpfResonance = 0.0
+	p.LpfFreq = 1.0
+	p.LpfRamp = 0.0
+	p.HpfFreq = 0.0
+	p.HpfRamp = 0.0
+
+	p.PhaOffset = 0.0
+	p.PhaRamp = 0.0
+
+	p.RepeatSpeed = 0.0
+
+	p.ArpSpeed = 0.0
+	p.ArpMod = 0.0
+
+	p.CrushBits = 0.0
+	p.CrushBitsRamp = 0.0
+	p.CrushRate = 0.0
+	p.CrushRateRamp = 0.0
+
+	p.Compression = 0.0
+	p.Makeup = 0.0
+
+	p.BandLimited = false
+
+	p.Seed = 0
+}
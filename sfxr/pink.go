@@ -0,0 +1,40 @@
+package sfxr
+
+// pinkNoise is a Voss-McCartney pink noise generator, as used by bfxr. It
+// sums five white noise sources, the nth of which is redrawn every 2^n
+// samples.
+type pinkNoise struct {
+	key   int
+	white [5]float32
+}
+
+const pinkRange float32 = 128
+
+func (n *pinkNoise) reset(s *Synth) {
+	n.key = 0
+	for i := range n.white {
+		n.white[i] = s.frnd(pinkRange / 5)
+	}
+}
+
+// next returns the next pink noise value in [-1, 1].
+func (n *pinkNoise) next(s *Synth) float32 {
+	last := n.key
+	n.key = (n.key + 1) & 0x1f
+	diff := last ^ n.key
+	var sum float32
+	for i := range n.white {
+		if diff&(1<<i) != 0 {
+			n.white[i] = s.frnd(pinkRange / 5)
+		}
+		sum += n.white[i]
+	}
+	return sum/(pinkRange/2) - 1
+}
+
+// fillPink refills the pink noise buffer that is played over one period.
+func (s *Synth) fillPink() {
+	for i := range s.pink_buffer {
+		s.pink_buffer[i] = s.pink.next(s)
+	}
+}
@@ -0,0 +1,194 @@
+package sfxr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// DefaultMaxSamples is the default for Synth.MaxSamples: about 10 seconds,
+// well beyond the longest envelope sfxr can produce.
+const DefaultMaxSamples = 44100 * 10
+
+// DefaultGain is the default for Synth.Gain. It brings sounds made with the
+// editor's default volume to a reasonable export level.
+const DefaultGain = 4.0
+
+// Sample rates other than the synthesizer's own 44100 Hz are resampled.
+const (
+	MinSampleRate = 8000
+	MaxSampleRate = 192000
+)
+
+func checkRate(freq int) error {
+	if freq < MinSampleRate || freq > MaxSampleRate {
+		return fmt.Errorf("unsupported sample rate %d", freq)
+	}
+	return nil
+}
+
+func checkBits(bits int) error {
+	if bits != 8 && bits != 16 && bits != 24 && bits != 32 {
+		return fmt.Errorf("unsupported bit depth %d", bits)
+	}
+	return nil
+}
+
+// Render renders the whole sound described by p at the given sample rate.
+// See Synth.Render.
+func Render(p Params, freq int) (samples []float32, truncated bool, err error) {
+	return NewSynth(p).Render(freq)
+}
+
+// Render renders the whole sound at the given sample rate (8000 to 192000
+// Hz) and returns its samples in [-1, 1]. They are the samples ExportWAV
+// writes, before quantization: normalized, clipped, trimmed and faded as
+// configured. truncated reports whether the sound was cut short by
+// MaxSamples.
+func (s *Synth) Render(freq int) (samples []float32, truncated bool, err error) {
+	if err := checkRate(freq); err != nil {
+		return nil, false, err
+	}
+	s.startExport(freq)
+	var block [256]float32
+	for {
+		n := s.render(block[:])
+		if n == 0 {
+			break
+		}
+		samples = append(samples, block[:n]...)
+	}
+	truncated = s.playing_sample
+	s.playing_sample = false
+
+	s.normalize(samples, freq)
+	for i, v := range samples {
+		samples[i] = s.clipSample(v)
+	}
+	if s.TrimThreshold < 0 {
+		samples = trimSilence(samples, s.TrimThreshold)
+	}
+	fade(samples, freq, s.FadeIn, s.FadeOut)
+	s.report.Samples = len(samples)
+	s.report.Truncated = truncated
+	s.report.Levels = MeasureLevels(samples, freq)
+	return samples, truncated, nil
+}
+
+// Report describes a played, streamed or exported sound.
+type Report struct {
+	Samples   int  // length at the output sample rate, after trimming
+	Truncated bool // cut short by MaxSamples
+
+	// Clipped counts the samples that exceeded full scale and were clipped,
+	// and MaxLevel is the largest magnitude before clipping, where 1 is
+	// full scale.
+	Clipped  int
+	MaxLevel float32
+
+	// Levels are measured on the samples Render returns. PCM streams only
+	// measure them when they are normalized, trimmed or faded.
+	Levels Levels
+}
+
+// processed reports whether exports need the whole sound before they can be
+// written, to normalize, trim or fade it.
+func (s *Synth) processed() bool {
+	return s.Normalize != NormalizeOff || s.TrimThreshold < 0 || s.FadeIn > 0 || s.FadeOut > 0
+}
+
+// Report returns the report for the sound last started by PlaySample, Samples,
+// Render, WriteWAV, ExportWAV or PCM. During playback it covers the samples
+// SynthSample has rendered so far.
+func (s *Synth) Report() Report {
+	return s.report
+}
+
+// startExport starts the sound for rendering at freq.
+func (s *Synth) startExport(freq int) {
+	s.file_sampleswritten = 0
+	s.resampler = nil
+	if freq != 44100 {
+		s.resampler = newResampler(44100, freq)
+	}
+	s.PlaySample()
+}
+
+// limited reports whether the sound has reached MaxSamples.
+func (s *Synth) limited() bool {
+	return s.MaxSamples > 0 && s.file_sampleswritten >= s.MaxSamples
+}
+
+// render fills buf with export samples, which are not clipped yet, and
+// returns how many it wrote. That is less than len(buf) once the sound ends
+// or reaches MaxSamples.
+func (s *Synth) render(buf []float32) (n int) {
+	r := s.resampler
+	for n < len(buf) {
+		if r != nil {
+			if v, ok := r.next(); ok {
+				buf[n] = v
+				n++
+				continue
+			}
+		}
+		if !s.playing_sample || s.limited() {
+			if r == nil || r.ended {
+				break
+			}
+			r.end()
+			continue
+		}
+		v := s.exportSample(s.synthOne())
+		if r != nil {
+			r.push(v)
+		} else {
+			buf[n] = v
+			n++
+		}
+	}
+	return n
+}
+
+// exportSample turns a synthesized sample into an export sample at 44.1kHz.
+func (s *Synth) exportSample(ssample float32) float32 {
+	s.file_sampleswritten++
+	return ssample * s.Gain
+}
+
+// clipSample clips a playback or export sample and records it in the report.
+func (s *Synth) clipSample(v float32) float32 {
+	if a := float32(math.Abs(float64(v))); a > s.report.MaxLevel {
+		s.report.MaxLevel = a
+	}
+	if v > 1.0 || v < -1.0 {
+		s.report.Clipped++
+	}
+	return clip(v)
+}
+
+func clip(v float32) float32 {
+	if v > 1.0 {
+		return 1.0
+	}
+	if v < -1.0 {
+		return -1.0
+	}
+	return v
+}
+
+// appendPCM appends v to data as a little-endian sample of the given bit
+// depth: unsigned 8-bit, signed 16 or 24-bit, or 32-bit float.
+func appendPCM(data []byte, v float32, bits int) []byte {
+	switch bits {
+	case 8:
+		return append(data, uint8(v*127+128))
+	case 16:
+		return binary.LittleEndian.AppendUint16(data, uint16(int16(v*32767)))
+	case 24:
+		x := int32(v * 8388607)
+		return append(data, byte(x), byte(x>>8), byte(x>>16))
+	default:
+		return binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+}
@@ -0,0 +1,82 @@
+package sfxr
+
+import (
+	"bytes"
+	"testing"
+)
+
+// playback plays p and returns up to n of its samples at playback level.
+func playback(p Params, n int) []float32 {
+	s := NewSynth(p)
+	s.PlaySample()
+	buf := make([]float32, n)
+	n = s.SynthSample(n, buf)
+	return buf[:n]
+}
+
+func TestRender(t *testing.T) {
+	p := loadGolden(t, "saw_slide")
+	var wav bytes.Buffer
+	if err := NewSynth(p).WriteWAV(&wav, 22050, 16); err != nil {
+		t.Fatal(err)
+	}
+	samples, truncated, err := Render(p, 22050)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated {
+		t.Error("sound reported as truncated")
+	}
+	if want := (wav.Len() - wavHeaderSize) / 2; len(samples) != want {
+		t.Errorf("rendered %d samples, want %d", len(samples), want)
+	}
+}
+
+func TestRenderMaxSamples(t *testing.T) {
+	s := NewSynth(loadGolden(t, "saw_slide"))
+	s.MaxSamples = 1000
+	samples, truncated, err := s.Render(22050)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("sound not reported as truncated")
+	}
+	if len(samples) != 500 {
+		t.Errorf("rendered %d samples, want 500", len(samples))
+	}
+	if s.Playing() {
+		t.Error("synth still playing after Render")
+	}
+}
+
+func TestRenderClipping(t *testing.T) {
+	p := loadGolden(t, "saw_slide")
+	s := NewSynth(p)
+	s.Gain = 0.5
+	if _, _, err := s.Render(44100); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Report(); r.Clipped != 0 || r.MaxLevel > 1 || r.MaxLevel == 0 {
+		t.Errorf("quiet sound reported as %+v", r)
+	}
+
+	s.Gain = 40
+	samples, _, err := s.Render(44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := 0
+	for _, v := range samples {
+		if v == 1 || v == -1 {
+			full++
+		}
+	}
+	r := s.Report()
+	if r.Clipped == 0 || r.Clipped != full {
+		t.Errorf("%d samples clipped, reported %d", full, r.Clipped)
+	}
+	if r.MaxLevel <= 1 {
+		t.Errorf("max level %v before clipping, want more than 1", r.MaxLevel)
+	}
+}
@@ -0,0 +1,111 @@
+package sfxr
+
+import "math"
+
+// The resampler converts the synthesizer's 44.1kHz output to other sample
+// rates with a Kaiser-windowed sinc filter. The filter spans resampleZeros
+// zero crossings on either side and cuts off a little below the lower of
+// the two Nyquist frequencies, so downsampling does not alias.
+const (
+	resampleZeros  = 32
+	resampleCutoff = 0.92
+	resampleBeta   = 8.6 // about 86 dB of stopband attenuation
+	resampleSteps  = 256 // kernel table entries per input sample
+)
+
+type resampler struct {
+	from, to int
+	fc       float64   // cutoff relative to the input Nyquist frequency
+	half     int       // kernel half-width in input samples
+	kernel   []float32 // kernel from distance 0 to half, resampleSteps per sample
+
+	in    []float32 // input samples from base onwards
+	base  int
+	total int  // input samples pushed so far
+	ended bool // no more input will be pushed
+	out   int  // output samples produced so far
+}
+
+func newResampler(from, to int) *resampler {
+	r := &resampler{from: from, to: to}
+	r.fc = resampleCutoff * math.Min(1, float64(to)/float64(from))
+	width := resampleZeros / r.fc
+	r.half = int(math.Ceil(width))
+	r.kernel = make([]float32, r.half*resampleSteps+2)
+	for i := range r.kernel {
+		d := float64(i) / resampleSteps
+		if d >= width {
+			break
+		}
+		x := d / width
+		w := bessel0(resampleBeta*math.Sqrt(1-x*x)) / bessel0(resampleBeta)
+		r.kernel[i] = float32(r.fc * sinc(r.fc*d) * w)
+	}
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// bessel0 is the zeroth order modified Bessel function of the first kind.
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / 2 / float64(k)) * (x / 2 / float64(k))
+		sum += term
+	}
+	return sum
+}
+
+// push adds the next input sample.
+func (r *resampler) push(v float32) {
+	r.in = append(r.in, v)
+	r.total++
+}
+
+// end marks the end of the input, so the remaining output can be produced.
+func (r *resampler) end() {
+	r.ended = true
+}
+
+// next returns the next output sample, or false if more input is needed or
+// the output is complete.
+func (r *resampler) next() (float32, bool) {
+	// The output sample lies at input position i + frac.
+	pos := r.out * r.from
+	i := pos / r.to
+	frac := float64(pos%r.to) / float64(r.to)
+	if r.ended {
+		if r.out >= (r.total*r.to+r.from-1)/r.from {
+			return 0, false
+		}
+	} else if i+r.half >= r.base+len(r.in) {
+		return 0, false
+	}
+
+	var sum float32
+	for j := i - r.half + 1; j <= i+r.half; j++ {
+		if j < r.base || j >= r.base+len(r.in) {
+			continue // before the start or after the end of the sound
+		}
+		d := math.Abs(float64(j-i)-frac) * resampleSteps
+		k := int(d)
+		if k+1 >= len(r.kernel) {
+			continue
+		}
+		t := float32(d - float64(k))
+		sum += r.in[j-r.base] * (r.kernel[k] + (r.kernel[k+1]-r.kernel[k])*t)
+	}
+	r.out++
+
+	// Drop input that later output samples no longer need.
+	if drop := i - r.half + 1 - r.base; drop > 4096 {
+		r.in = append(r.in[:0], r.in[drop:]...)
+		r.base += drop
+	}
+	return sum, true
+}
@@ -0,0 +1,65 @@
+package sfxr
+
+import (
+	"math"
+	"testing"
+)
+
+// resampleTone resamples a sine tone of the given frequency from 44.1kHz and
+// returns its RMS level and the RMS error against the ideal tone at the output
+// rate, measured away from the edges.
+func resampleTone(freq float64, to int) (level, errLevel float64) {
+	const n = 44100
+	r := newResampler(44100, to)
+	var out []float32
+	for i := 0; i < n; i++ {
+		r.push(float32(math.Sin(2 * math.Pi * freq * float64(i) / 44100)))
+		for v, ok := r.next(); ok; v, ok = r.next() {
+			out = append(out, v)
+		}
+	}
+	r.end()
+	for v, ok := r.next(); ok; v, ok = r.next() {
+		out = append(out, v)
+	}
+
+	var sum, errSum float64
+	count := 0
+	for i := len(out) / 4; i < len(out)*3/4; i++ {
+		want := math.Sin(2 * math.Pi * freq * float64(i) / float64(to))
+		sum += float64(out[i]) * float64(out[i])
+		errSum += (float64(out[i]) - want) * (float64(out[i]) - want)
+		count++
+	}
+	return math.Sqrt(sum / float64(count)), math.Sqrt(errSum / float64(count))
+}
+
+func TestResampler(t *testing.T) {
+	for _, to := range []int{8000, 11025, 22050, 32000, 48000, 96000} {
+		if _, e := resampleTone(1000, to); e > 1e-3 {
+			t.Errorf("%d Hz: 1 kHz tone has RMS error %.2g", to, e)
+		}
+	}
+	// A tone above the output Nyquist frequency must be filtered out
+	// instead of aliasing.
+	for _, to := range []int{8000, 22050, 32000} {
+		if level, _ := resampleTone(float64(to)*0.55, to); level > 1e-3 {
+			t.Errorf("%d Hz: tone above Nyquist leaks through at RMS %.2g", to, level)
+		}
+	}
+}
+
+func TestResamplerLength(t *testing.T) {
+	r := newResampler(44100, 48000)
+	for i := 0; i < 44100; i++ {
+		r.push(0)
+	}
+	r.end()
+	n := 0
+	for _, ok := r.next(); ok; _, ok = r.next() {
+		n++
+	}
+	if n != 48000 {
+		t.Fatalf("resampled 44100 samples to %d, want 48000", n)
+	}
+}
@@ -0,0 +1,297 @@
+package sfxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSettingsSize bounds how much of a settings file is read. Real files are
+// a few hundred bytes; the limit only guards against hostile input.
+const maxSettingsSize = 64 << 10
+
+// readLimited reads all of r, failing once it exceeds maxSettingsSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSettingsSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSettingsSize {
+		return nil, fmt.Errorf("settings larger than %d bytes", maxSettingsSize)
+	}
+	return data, nil
+}
+
+// replaceFile writes filename through write. The data goes to a temporary
+// file in the same directory, which is renamed over filename only once it is
+// complete, so a failed write never leaves a truncated file in place of an
+// existing one.
+func replaceFile(filename string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if err == nil {
+		err = file.Chmod(mode)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// LoadSettings reads parameters from a binary .cfg file written by sfxr or
+// from a JSON file. The format is detected from the file contents. Values out
+// of range are clamped, and a warning is returned for each of them.
+func (p *Params) LoadSettings(filename string) (warnings []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	warnings, err = p.ReadSettings(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return warnings, nil
+}
+
+// ReadSettings is like LoadSettings but reads the settings from r. It reads
+// at most 64 KiB and leaves p unchanged on error.
+func (p *Params) ReadSettings(r io.Reader) (warnings []string, err error) {
+	data, err := readLimited(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.DecodeSettings(data)
+}
+
+// DecodeSettings is like ReadSettings but decodes the settings from data.
+func (p *Params) DecodeSettings(data []byte) (warnings []string, err error) {
+	if len(data) > maxSettingsSize {
+		return nil, fmt.Errorf("settings larger than %d bytes", maxSettingsSize)
+	}
+	if isJSON(data) {
+		err = p.loadJSON(data)
+	} else {
+		err = p.loadBinary(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Sanitize(), nil
+}
+
+// settingsReader reads little-endian fields and keeps the first error, so
+// a truncated file reports the field it stopped at.
+type settingsReader struct {
+	r   io.Reader
+	err error
+}
+
+func (sr *settingsReader) read(name string, data any) {
+	if sr.err != nil {
+		return
+	}
+	err := binary.Read(sr.r, binary.LittleEndian, data)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		sr.err = fmt.Errorf("settings truncated at %s", name)
+	} else if err != nil {
+		sr.err = fmt.Errorf("reading %s: %w", name, err)
+	}
+}
+
+func (p *Params) loadBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	file := &settingsReader{r: r}
+	// Fields that older versions lack keep their defaults.
+	q := DefaultParams()
+	var version int32
+	file.read("version", &version)
+	if file.err != nil {
+		return file.err
+	}
+	if version < 100 || version > 106 {
+		return fmt.Errorf("unknown settings version %d", version)
+	}
+
+	var wt int32
+	file.read("wave_type", &wt)
+	q.WaveType = int(wt)
+
+	if version >= 102 {
+		file.read("sound_vol", &q.SoundVol)
+	}
+
+	file.read("base_freq", &q.BaseFreq)
+	file.read("freq_limit", &q.FreqLimit)
+	file.read("freq_ramp", &q.FreqRamp)
+	if version >= 101 {
+		file.read("freq_dramp", &q.FreqDramp)
+	}
+	file.read("duty", &q.Duty)
+	file.read("duty_ramp", &q.DutyRamp)
+
+	file.read("vib_strength", &q.VibStrength)
+	file.read("vib_speed", &q.VibSpeed)
+	file.read("vib_delay", &q.VibDelay)
+
+	file.read("env_attack", &q.EnvAttack)
+	file.read("env_sustain", &q.EnvSustain)
+	file.read("env_decay", &q.EnvDecay)
+	file.read("env_punch", &q.EnvPunch)
+
+	file.read("filter_on", &q.FilterOn)
+	file.read("lpf_resonance", &q.LpfResonance)
+	file.read("lpf_freq", &q.LpfFreq)
+	file.read("lpf_ramp", &q.LpfRamp)
+	file.read("hpf_freq", &q.HpfFreq)
+	file.read("hpf_ramp", &q.HpfRamp)
+
+	file.read("pha_offset", &q.PhaOffset)
+	file.read("pha_ramp", &q.PhaRamp)
+
+	file.read("repeat_speed", &q.RepeatSpeed)
+
+	if version >= 101 {
+		file.read("arp_speed", &q.ArpSpeed)
+		file.read("arp_mod", &q.ArpMod)
+	}
+
+	if version >= 104 {
+		file.read("band_limited", &q.BandLimited)
+	}
+
+	if version >= 105 {
+		file.read("crush_bits", &q.CrushBits)
+		file.read("crush_bits_ramp", &q.CrushBitsRamp)
+		file.read("crush_rate", &q.CrushRate)
+		file.read("crush_rate_ramp", &q.CrushRateRamp)
+	}
+
+	if version >= 106 {
+		file.read("compression", &q.Compression)
+		file.read("makeup", &q.Makeup)
+	}
+
+	if file.err != nil {
+		return file.err
+	}
+
+	// The noise seed is an optional trailer that other sfxr ports ignore.
+	if err := binary.Read(r, binary.LittleEndian, &q.Seed); err != nil && err != io.EOF {
+		return fmt.Errorf("settings truncated at seed")
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%d bytes of unexpected data after settings", r.Len())
+	}
+
+	*p = q
+	return nil
+}
+
+// settingsVersion returns the oldest binary format that can hold p, so that
+// sounds using only the classic features stay readable by the original sfxr.
+// Version 103 adds the extra waveforms to the layout of version 102, version
+// 104 appends the band-limited flag, version 105 the bit crusher and version
+// 106 the compressor.
+func (p *Params) settingsVersion() int32 {
+	switch {
+	case p.Compression != 0 || p.Makeup != 0:
+		return 106
+	case p.CrushBits != 0 || p.CrushBitsRamp != 0 || p.CrushRate != 0 || p.CrushRateRamp != 0:
+		return 105
+	case p.BandLimited:
+		return 104
+	case p.WaveType >= 4:
+		return 103
+	}
+	return 102
+}
+
+// SaveSettings writes the parameters to a binary .cfg file, or to a JSON
+// file when filename has a .json extension.
+func (p *Params) SaveSettings(filename string) error {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return p.saveJSON(filename)
+	}
+
+	file := new(bytes.Buffer)
+
+	version := p.settingsVersion()
+	binary.Write(file, binary.LittleEndian, version)
+
+	binary.Write(file, binary.LittleEndian, int32(p.WaveType))
+
+	binary.Write(file, binary.LittleEndian, p.SoundVol)
+
+	binary.Write(file, binary.LittleEndian, p.BaseFreq)
+	binary.Write(file, binary.LittleEndian, p.FreqLimit)
+	binary.Write(file, binary.LittleEndian, p.FreqRamp)
+	binary.Write(file, binary.LittleEndian, p.FreqDramp)
+	binary.Write(file, binary.LittleEndian, p.Duty)
+	binary.Write(file, binary.LittleEndian, p.DutyRamp)
+
+	binary.Write(file, binary.LittleEndian, p.VibStrength)
+	binary.Write(file, binary.LittleEndian, p.VibSpeed)
+	binary.Write(file, binary.LittleEndian, p.VibDelay)
+
+	binary.Write(file, binary.LittleEndian, p.EnvAttack)
+	binary.Write(file, binary.LittleEndian, p.EnvSustain)
+	binary.Write(file, binary.LittleEndian, p.EnvDecay)
+	binary.Write(file, binary.LittleEndian, p.EnvPunch)
+
+	binary.Write(file, binary.LittleEndian, p.FilterOn)
+	binary.Write(file, binary.LittleEndian, p.LpfResonance)
+	binary.Write(file, binary.LittleEndian, p.LpfFreq)
+	binary.Write(file, binary.LittleEndian, p.LpfRamp)
+	binary.Write(file, binary.LittleEndian, p.HpfFreq)
+	binary.Write(file, binary.LittleEndian, p.HpfRamp)
+
+	binary.Write(file, binary.LittleEndian, p.PhaOffset)
+	binary.Write(file, binary.LittleEndian, p.PhaRamp)
+
+	binary.Write(file, binary.LittleEndian, p.RepeatSpeed)
+
+	binary.Write(file, binary.LittleEndian, p.ArpSpeed)
+	binary.Write(file, binary.LittleEndian, p.ArpMod)
+
+	if version >= 104 {
+		binary.Write(file, binary.LittleEndian, p.BandLimited)
+	}
+	if version >= 105 {
+		binary.Write(file, binary.LittleEndian, p.CrushBits)
+		binary.Write(file, binary.LittleEndian, p.CrushBitsRamp)
+		binary.Write(file, binary.LittleEndian, p.CrushRate)
+		binary.Write(file, binary.LittleEndian, p.CrushRateRamp)
+	}
+	if version >= 106 {
+		binary.Write(file, binary.LittleEndian, p.Compression)
+		binary.Write(file, binary.LittleEndian, p.Makeup)
+	}
+
+	binary.Write(file, binary.LittleEndian, p.Seed)
+
+	return replaceFile(filename, func(w io.Writer) error {
+		_, err := file.WriteTo(w)
+		return err
+	})
+}
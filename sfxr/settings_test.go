@@ -0,0 +1,230 @@
+package sfxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeSettings returns p as a binary .cfg file.
+func encodeSettings(t *testing.T, p Params) []byte {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "sound.cfg")
+	if err := p.SaveSettings(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func addGoldenSettings(f *testing.F) {
+	cfgs, _ := filepath.Glob("testdata/golden/*.cfg")
+	for _, cfg := range cfgs {
+		data, err := os.ReadFile(cfg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+		f.Add(data[:len(data)-8]) // without the seed trailer
+		f.Add(data[:len(data)/2])
+	}
+}
+
+func TestDecodeSettingsTrailingData(t *testing.T) {
+	data := encodeSettings(t, DefaultParams())
+	p := DefaultParams()
+	if _, err := p.DecodeSettings(append(data, 0)); err == nil {
+		t.Fatal("trailing byte accepted")
+	}
+	if _, err := p.DecodeSettings(data[:len(data)-3]); err == nil {
+		t.Fatal("partial seed accepted")
+	}
+}
+
+// endless is a reader that never runs out of whitespace.
+type endless struct{}
+
+func (endless) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = ' '
+	}
+	return len(b), nil
+}
+
+func TestReadSettingsLimit(t *testing.T) {
+	p := DefaultParams()
+	if _, err := p.ReadSettings(endless{}); err == nil {
+		t.Fatal("endless input accepted")
+	}
+}
+
+// FuzzDecodeSettings checks that arbitrary input never panics, and that any
+// input that loads yields valid parameters that save and load unchanged and
+// can be synthesized.
+func FuzzDecodeSettings(f *testing.F) {
+	addGoldenSettings(f)
+	f.Add([]byte(`{"wave_type": 3, "base_freq": 2, "seed": 9}`))
+	f.Add([]byte(`{"p_base_freq": 0.3, "wave_type": 7}`))
+	f.Add([]byte{102, 0, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := DefaultParams()
+		before := p
+		if _, err := p.DecodeSettings(data); err != nil {
+			if p != before {
+				t.Fatalf("failed load changed the parameters: %v", err)
+			}
+			return
+		}
+		if err := p.Validate(); err != nil {
+			t.Fatalf("loaded invalid parameters: %v", err)
+		}
+
+		var q Params
+		warnings, err := q.DecodeSettings(encodeSettings(t, p))
+		if err != nil {
+			t.Fatalf("reloading saved settings: %v", err)
+		}
+		if len(warnings) > 0 || q != p {
+			t.Fatalf("round trip changed the parameters: %v\n got %+v\nwant %+v", warnings, q, p)
+		}
+
+		s := NewSynth(p)
+		s.PlaySample()
+		s.SynthSample(4096, make([]float32, 4096))
+	})
+}
+
+// FuzzImport checks that the jsfxr and bfxr importers never panic and only
+// produce valid parameters.
+func FuzzImport(f *testing.F) {
+	p := DefaultParams()
+	if _, err := p.LoadSettings("testdata/golden/saw_slide.cfg"); err != nil {
+		f.Fatal(err)
+	}
+	share, _ := p.JsfxrB58()
+	object, _ := p.JsfxrJSON()
+	f.Add(share)
+	f.Add("https://sfxr.me/#" + share)
+	f.Add(object)
+	f.Add(`{"oldParams": true, "wave_type": 1, "p_base_freq": 0.5, "p_env_decay": 2}`)
+	f.Add("0,0.5,0,0.3,0,0.4,0,0.3,0,0,0,0,0,0,0,0,0,0,0,0,0.5,0,0,0,0,1,0,0,0,0,0,0")
+	f.Add(`{"waveType": 8, "startFrequency": 0.4, "bitCrush": 0.5}`)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, load := range []func(*Params, string) ([]string, error){
+			(*Params).ImportJsfxr,
+			(*Params).ImportBfxr,
+		} {
+			p := DefaultParams()
+			if _, err := load(&p, s); err != nil {
+				continue
+			}
+			if err := p.Validate(); err != nil {
+				t.Fatalf("imported invalid parameters from %q: %v", s, err)
+			}
+		}
+	})
+}
+
+func TestReadSettingsMatchesLoad(t *testing.T) {
+	data, err := os.ReadFile("testdata/golden/noise.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a, b Params
+	if _, err := a.LoadSettings("testdata/golden/noise.cfg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.ReadSettings(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("ReadSettings = %+v, LoadSettings = %+v", b, a)
+	}
+}
+
+func TestSettingsVersion(t *testing.T) {
+	for _, tt := range []struct {
+		waveType    int
+		bandLimited bool
+		crushBits   float32
+		compression float32
+		version     uint32
+	}{
+		{3, false, 0, 0, 102}, // classic sounds stay readable by sfxr
+		{4, false, 0, 0, 103},
+		{8, false, 0, 0, 103},
+		{0, true, 0, 0, 104},
+		{0, false, 0.5, 0, 105},
+		{0, false, 0, 0.5, 106},
+	} {
+		p := DefaultParams()
+		p.WaveType = tt.waveType
+		p.BandLimited = tt.bandLimited
+		p.CrushBits = tt.crushBits
+		p.Compression = tt.compression
+		data := encodeSettings(t, p)
+		if v := binary.LittleEndian.Uint32(data); v != tt.version {
+			t.Errorf("%+v saved as version %d, want %d", tt, v, tt.version)
+		}
+		var q Params
+		if _, err := q.DecodeSettings(data); err != nil || q != p {
+			t.Errorf("%+v: round trip gave %+v, %v", tt, q, err)
+		}
+	}
+}
+
+func TestReplaceFileKeepsOldFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "sound.cfg")
+	if err := os.WriteFile(filename, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	errWrite := errors.New("write failed")
+	err := replaceFile(filename, func(w io.Writer) error {
+		w.Write([]byte("ne"))
+		return errWrite
+	})
+	if err != errWrite {
+		t.Fatalf("got error %v, want %v", err, errWrite)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "old" {
+		t.Errorf("file holds %q after a failed write", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestLoadVersion100IgnoresReceiver(t *testing.T) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(100))
+	binary.Write(&buf, binary.LittleEndian, int32(1))
+	binary.Write(&buf, binary.LittleEndian, make([]float32, 12))
+	binary.Write(&buf, binary.LittleEndian, false)
+	binary.Write(&buf, binary.LittleEndian, make([]float32, 8))
+
+	var fresh Params
+	if _, err := fresh.DecodeSettings(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	used := DefaultParams()
+	used.FreqDramp = 0.5
+	used.ArpSpeed = 0.5
+	used.ArpMod = 0.5
+	used.SoundVol = 0.9
+	if _, err := used.DecodeSettings(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if used != fresh {
+		t.Errorf("version 100 file loaded as %+v over earlier settings, %+v on its own", used, fresh)
+	}
+}
@@ -0,0 +1,106 @@
+package sfxr
+
+import (
+	"bytes"
+	"io"
+)
+
+// SampleReader streams a sound as float32 samples at 44.1kHz, in the same
+// form SynthSample writes to its playback buffer.
+type SampleReader struct {
+	s *Synth
+	n int
+}
+
+// Samples starts the sound and returns a reader of its samples. The Synth
+// should not be used for anything else until the reader reaches io.EOF.
+func (s *Synth) Samples() *SampleReader {
+	s.PlaySample()
+	return &SampleReader{s: s}
+}
+
+// Read renders up to len(buf) samples into buf and returns how many it
+// rendered. After the last sample it returns 0, io.EOF.
+func (r *SampleReader) Read(buf []float32) (int, error) {
+	max := r.s.MaxSamples
+	if !r.s.playing_sample || (max > 0 && r.n >= max) {
+		if r.s.playing_sample {
+			r.s.report.Truncated = true
+		}
+		r.s.playing_sample = false
+		return 0, io.EOF
+	}
+	if max > 0 && len(buf) > max-r.n {
+		buf = buf[:max-r.n]
+	}
+	n := r.s.SynthSample(len(buf), buf)
+	r.n += n
+	return n, nil
+}
+
+// pcmReader renders the sound in blocks and hands out the quantized bytes.
+// A sound that is normalized, trimmed or faded has to be rendered in full
+// first, so it is taken from samples instead.
+type pcmReader struct {
+	s       *Synth
+	q       *quantizer
+	block   [256]float32
+	whole   bool
+	samples []float32
+	buf     bytes.Buffer
+}
+
+// PCM starts the sound and returns a reader of its mono, little-endian PCM
+// data at the given sample rate and bit depth, as for ExportWAV. This is the
+// data ExportWAV writes after the WAV header. The Synth should not be used
+// for anything else until the reader reaches io.EOF.
+func (s *Synth) PCM(freq, bits int) (io.Reader, error) {
+	if err := checkRate(freq); err != nil {
+		return nil, err
+	}
+	if err := checkBits(bits); err != nil {
+		return nil, err
+	}
+	r := &pcmReader{s: s, q: newQuantizer(bits, s.Dither, s.Params.Seed)}
+	if s.processed() {
+		r.whole = true
+		r.samples, _, _ = s.Render(freq)
+	} else {
+		s.startExport(freq)
+	}
+	return r, nil
+}
+
+// next fills r.block with the next samples and returns how many there are.
+func (r *pcmReader) next() int {
+	if r.whole {
+		n := copy(r.block[:], r.samples)
+		r.samples = r.samples[n:]
+		return n
+	}
+	n := r.s.render(r.block[:])
+	for i, v := range r.block[:n] {
+		r.block[i] = r.s.clipSample(v)
+	}
+	r.s.report.Samples += n
+	if n == 0 {
+		r.s.report.Truncated = r.s.playing_sample
+	}
+	return n
+}
+
+func (r *pcmReader) Read(b []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		n := r.next()
+		if n == 0 {
+			r.s.playing_sample = false // ensure we don't leave playback stuck
+			return 0, io.EOF
+		}
+		data := r.buf.AvailableBuffer()
+		for _, v := range r.block[:n] {
+			data = r.q.append(data, v)
+		}
+		r.buf.Write(data)
+	}
+	return r.buf.Read(b)
+}
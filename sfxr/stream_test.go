@@ -0,0 +1,93 @@
+package sfxr
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func loadGolden(t *testing.T, name string) Params {
+	t.Helper()
+	p := DefaultParams()
+	if _, err := p.LoadSettings("testdata/golden/" + name + ".cfg"); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPCMMatchesWAV(t *testing.T) {
+	p := loadGolden(t, "noise_repeat")
+	for _, format := range []struct{ freq, bits int }{{44100, 16}, {22050, 8}} {
+		var wav bytes.Buffer
+		if err := NewSynth(p).WriteWAV(&wav, format.freq, format.bits); err != nil {
+			t.Fatal(err)
+		}
+		pcm, err := NewSynth(p).PCM(format.freq, format.bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A small, odd buffer size exercises reads that split blocks.
+		var data bytes.Buffer
+		if _, err := io.CopyBuffer(struct{ io.Writer }{&data}, pcm, make([]byte, 7)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data.Bytes(), wav.Bytes()[wavHeaderSize:]) {
+			t.Errorf("%d Hz %d bits: PCM stream differs from WAV data", format.freq, format.bits)
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	p := loadGolden(t, "square")
+	var wav bytes.Buffer
+	if err := NewSynth(p).WriteWAV(&wav, 44100, 16); err != nil {
+		t.Fatal(err)
+	}
+	want := (wav.Len() - wavHeaderSize) / 2
+
+	r := NewSynth(p).Samples()
+	buf := make([]float32, 1000)
+	total := 0
+	for {
+		n, err := r.Read(buf)
+		total += n
+		for _, v := range buf[:n] {
+			if v < -1 || v > 1 {
+				t.Fatalf("sample %v out of range", v)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if total != want {
+		t.Fatalf("read %d samples, want %d", total, want)
+	}
+}
+
+func TestSamplesReportClipping(t *testing.T) {
+	s := NewSynth(loadGolden(t, "square"))
+	s.MasterVol = 1 // far louder than playback normally is
+	r := s.Samples()
+	buf := make([]float32, 1000)
+	total, full := 0, 0
+	for {
+		n, err := r.Read(buf)
+		total += n
+		for _, v := range buf[:n] {
+			if v == 1 || v == -1 {
+				full++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	rep := s.Report()
+	if rep.Samples != total || rep.Clipped == 0 || rep.Clipped != full || rep.MaxLevel <= 1 {
+		t.Errorf("%d samples, %d clipped, reported %+v", total, full, rep)
+	}
+}
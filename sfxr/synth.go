@@ -0,0 +1,402 @@
+// Package sfxr implements the sfxr sound effect synthesizer.
+package sfxr
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	PI = 3.14159265
+)
+
+// Synth renders a sound described by Params. Each Synth owns its own
+// synthesis state, so several sounds can be rendered independently.
+type Synth struct {
+	Params    Params
+	MasterVol float32
+
+	// Gain scales exported sounds, which are clipped to [-1, 1] afterwards.
+	// It does not affect playback through SynthSample's buffer.
+	Gain float32
+
+	// Normalize scales exports to NormalizeTarget, in dBFS for peak and in
+	// LUFS for loudness normalization. It replaces the level set by Gain.
+	Normalize       Normalization
+	NormalizeTarget float64
+
+	// TrimThreshold, if below zero, trims the leading and trailing samples
+	// of exports that are quieter than this level in dBFS. FadeIn and
+	// FadeOut then apply linear ramps of these durations to the ends.
+	TrimThreshold   float64
+	FadeIn, FadeOut time.Duration
+
+	// Dither selects how exports are rounded to integer samples.
+	Dither Dither
+
+	// MaxSamples stops sounds that would otherwise never end after this
+	// many samples at 44.1kHz. Zero or less means no limit.
+	MaxSamples int
+
+	playing_sample bool
+	phase          int
+	fperiod        float64
+	fmaxperiod     float64
+	fslide         float64
+	fdslide        float64
+	period         int
+	square_duty    float32
+	square_slide   float32
+	env_stage      int
+	env_time       int
+	env_length     [3]int
+	env_vol        float32
+	fphase         float32
+	fdphase        float32
+	iphase         int
+	phaser_buffer  [1024]float32
+	ipp            int
+	noise_buffer   [32]float32
+	pink_buffer    [32]float32
+	pink           pinkNoise
+	lfsr           uint16
+	osc_phase      float64
+	crush_bits     float32
+	crush_bits_d   float32
+	crush_rate     float32
+	crush_rate_d   float32
+	crush_phase    float32
+	crush_hold     float32
+	comp_factor    float64
+	comp_gain      float32
+	fltp           float32
+	fltdp          float32
+	fltw           float32
+	fltw_d         float32
+	fltdmp         float32
+	fltphp         float32
+	flthp          float32
+	flthp_d        float32
+	vib_phase      float32
+	vib_speed      float32
+	vib_amp        float32
+	vib_delay      int
+	rep_time       int
+	rep_limit      int
+	arp_time       int
+	arp_limit      int
+	arp_mod        float64
+	rng            *rand.Rand
+
+	resampler *resampler
+	report    Report
+
+	file_sampleswritten int
+}
+
+// NewSynth returns a Synth that plays the given parameters.
+func NewSynth(p Params) *Synth {
+	return &Synth{
+		Params:     p,
+		MasterVol:  0.05,
+		Gain:       DefaultGain,
+		MaxSamples: DefaultMaxSamples,
+		rng:        rand.New(rand.NewSource(p.Seed)),
+	}
+}
+
+func (s *Synth) frnd(rangeVal float32) float32 {
+	return float32(s.rng.Intn(10000)) / 10000 * rangeVal
+}
+
+// Playing reports whether the synth is still producing sound.
+func (s *Synth) Playing() bool {
+	return s.playing_sample
+}
+
+// Stop ends playback of the current sound.
+func (s *Synth) Stop() {
+	s.playing_sample = false
+}
+
+func (s *Synth) ResetSample(restart bool) {
+	p := &s.Params
+	if !restart {
+		s.phase = 0
+		s.osc_phase = 0
+	}
+	s.fperiod = 100.0 / (float64(p.BaseFreq*p.BaseFreq) + 0.001)
+	s.period = int(s.fperiod)
+	s.fmaxperiod = 100.0 / (float64(p.FreqLimit*p.FreqLimit) + 0.001)
+	s.fslide = 1.0 - math.Pow(float64(p.FreqRamp), 3.0)*0.01
+	s.fdslide = -math.Pow(float64(p.FreqDramp), 3.0) * 0.000001
+	s.square_duty = 0.5 - p.Duty*0.5
+	s.square_slide = -p.DutyRamp * 0.00005
+	if p.ArpMod >= 0.0 {
+		s.arp_mod = 1.0 - math.Pow(float64(p.ArpMod), 2.0)*0.9
+	} else {
+		s.arp_mod = 1.0 + math.Pow(float64(p.ArpMod), 2.0)*10.0
+	}
+	s.arp_time = 0
+	s.arp_limit = int(math.Pow(float64(1.0-p.ArpSpeed), 2.0)*20000 + 32)
+	if p.ArpSpeed == 1.0 {
+		s.arp_limit = 0
+	}
+	if !restart {
+		// reset filter
+		s.fltp = 0.0
+		s.fltdp = 0.0
+		s.fltw = float32(math.Pow(float64(p.LpfFreq), 3.0) * 0.1)
+		s.fltw_d = 1.0 + p.LpfRamp*0.0001
+		s.fltdmp = 5.0 / (1.0 + float32(math.Pow(float64(p.LpfResonance), 2.0))*20.0) * (0.01 + s.fltw)
+		if s.fltdmp > 0.8 {
+			s.fltdmp = 0.8
+		}
+		s.fltphp = 0.0
+		s.flthp = float32(math.Pow(float64(p.HpfFreq), 2.0) * 0.1)
+		s.flthp_d = 1.0 + p.HpfRamp*0.0003
+		// reset bit crusher
+		s.resetCrush()
+		s.resetCompressor()
+		// reset vibrato
+		s.vib_phase = 0.0
+		s.vib_speed = float32(math.Pow(float64(p.VibSpeed), 2.0) * 0.01)
+		s.vib_amp = p.VibStrength * 0.5
+		s.vib_delay = int(p.VibDelay * p.VibDelay * 100000.0)
+		// reset envelope
+		s.env_vol = 0.0
+		s.env_stage = 0
+		s.env_time = 0
+		s.env_length[0] = int(p.EnvAttack * p.EnvAttack * 100000.0)
+		s.env_length[1] = int(p.EnvSustain * p.EnvSustain * 100000.0)
+		s.env_length[2] = int(p.EnvDecay * p.EnvDecay * 100000.0)
+
+		s.fphase = float32(math.Pow(float64(p.PhaOffset), 2.0) * 1020.0)
+		if p.PhaOffset < 0.0 {
+			s.fphase = -s.fphase
+		}
+		s.fdphase = float32(math.Pow(float64(p.PhaRamp), 2.0) * 1.0)
+		if p.PhaRamp < 0.0 {
+			s.fdphase = -s.fdphase
+		}
+		s.iphase = int(math.Abs(float64(s.fphase)))
+		s.ipp = 0
+		for i := 0; i < 1024; i++ {
+			s.phaser_buffer[i] = 0.0
+		}
+
+		s.rng.Seed(p.Seed)
+		for i := 0; i < 32; i++ {
+			s.noise_buffer[i] = s.frnd(2.0) - 1.0
+		}
+		if p.WaveType == 5 {
+			s.pink.reset(s)
+			s.fillPink()
+		}
+		if p.WaveType == 9 || p.WaveType == 10 {
+			s.lfsr = 1
+			s.fillLFSR(p.WaveType == 10)
+		}
+
+		s.rep_time = 0
+		s.rep_limit = int(math.Pow(float64(1.0-p.RepeatSpeed), 2.0)*20000 + 32)
+		if p.RepeatSpeed == 0.0 {
+			s.rep_limit = 0
+		}
+	}
+}
+
+// PlaySample starts the sound from the beginning and clears the report.
+func (s *Synth) PlaySample() {
+	s.ResetSample(false)
+	s.report = Report{}
+	s.playing_sample = true
+}
+
+// SynthSample renders up to length playback samples into buffer and returns
+// how many it rendered, which is less than length once the sound ends. The
+// samples are clipped to [-1, 1] and counted in the report. Files are written
+// by Render, WriteWAV and ExportWAV instead.
+func (s *Synth) SynthSample(length int, buffer []float32) int {
+	for i := 0; i < length; i++ {
+		if !s.playing_sample {
+			return i
+		}
+		buffer[i] = s.clipSample(s.synthOne())
+		s.report.Samples++
+	}
+	return length
+}
+
+// synthOne advances the sound by one sample and returns it at playback
+// level, before clipping.
+func (s *Synth) synthOne() float32 {
+	p := &s.Params
+	s.rep_time++
+	if s.rep_limit != 0 && s.rep_time >= s.rep_limit {
+		s.rep_time = 0
+		s.ResetSample(true)
+	}
+
+	// frequency envelopes/arpeggios
+	s.arp_time++
+	if s.arp_limit != 0 && s.arp_time >= s.arp_limit {
+		s.arp_limit = 0
+		s.fperiod *= s.arp_mod
+	}
+	s.fslide += s.fdslide
+	s.fperiod *= s.fslide
+	if s.fperiod > s.fmaxperiod {
+		s.fperiod = s.fmaxperiod
+		if p.FreqLimit > 0.0 {
+			s.playing_sample = false
+		}
+	}
+	rfperiod := s.fperiod
+	if s.vib_amp > 0.0 {
+		if s.vib_delay > 0 {
+			s.vib_delay--
+		} else {
+			s.vib_phase += s.vib_speed
+			rfperiod = s.fperiod * (1.0 + math.Sin(float64(s.vib_phase))*float64(s.vib_amp))
+		}
+	}
+	s.period = int(rfperiod)
+	if s.period < 8 {
+		s.period = 8
+	}
+	s.square_duty += s.square_slide
+	if s.square_duty < 0.0 {
+		s.square_duty = 0.0
+	}
+	if s.square_duty > 0.5 {
+		s.square_duty = 0.5
+	}
+	// volume envelope
+	s.env_time++
+	if s.env_time > s.env_length[s.env_stage] {
+		s.env_time = 0
+		s.env_stage++
+		if s.env_stage == 3 {
+			s.playing_sample = false
+		}
+	}
+	if s.env_stage == 0 {
+		s.env_vol = float32(s.env_time) / float32(s.env_length[0])
+	}
+	if s.env_stage == 1 {
+		s.env_vol = 1.0 + float32(math.Pow(1.0-float64(s.env_time)/float64(s.env_length[1]), 1.0))*2.0*p.EnvPunch
+	}
+	if s.env_stage == 2 {
+		s.env_vol = 1.0 - float32(s.env_time)/float32(s.env_length[2])
+	}
+
+	// phaser step
+	s.fphase += s.fdphase
+	s.iphase = int(math.Abs(float64(s.fphase)))
+	if s.iphase > 1023 {
+		s.iphase = 1023
+	}
+
+	if s.flthp_d != 0.0 {
+		s.flthp *= s.flthp_d
+		if s.flthp < 0.00001 {
+			s.flthp = 0.00001
+		}
+		if s.flthp > 0.1 {
+			s.flthp = 0.1
+		}
+	}
+
+	ssample := float32(0.0)
+	for si := 0; si < 8; si++ { // 8x supersampling
+		sample := float32(0.0)
+		s.phase++
+		if s.phase >= s.period {
+			// phase = 0
+			s.phase %= s.period
+			if p.WaveType == 3 {
+				for i := 0; i < 32; i++ {
+					s.noise_buffer[i] = s.frnd(2.0) - 1.0
+				}
+			}
+			if p.WaveType == 5 {
+				s.fillPink()
+			}
+			if p.WaveType == 9 || p.WaveType == 10 {
+				s.fillLFSR(p.WaveType == 10)
+			}
+		}
+		// base waveform
+		fp := float32(s.phase) / float32(s.period)
+		switch p.WaveType {
+		case 0: // square
+			if p.BandLimited {
+				sample = s.bandLimited(rfperiod)
+			} else if fp < s.square_duty {
+				sample = 0.5
+			} else {
+				sample = -0.5
+			}
+		case 1: // sawtooth
+			if p.BandLimited {
+				sample = s.bandLimited(rfperiod)
+			} else {
+				sample = 1.0 - fp*2
+			}
+		case 2: // sine
+			sample = float32(math.Sin(float64(fp) * 2 * PI))
+		case 3: // noise
+			sample = s.noise_buffer[s.phase*32/s.period]
+		case 4: // triangle
+			sample = float32(math.Abs(float64(1.0-fp*2)))*2 - 1
+		case 5: // pink noise
+			sample = s.pink_buffer[s.phase*32/s.period]
+		case 6: // tan, clamped so its poles don't swamp the filters
+			sample = float32(math.Tan(float64(fp) * PI))
+			sample = max(-2, min(2, sample))
+		case 7: // whistle, a sine with a quiet 20th harmonic
+			sample = float32(0.75*math.Sin(float64(fp)*2*PI) + 0.25*math.Sin(float64(fp)*40*PI))
+		case 8: // breaker
+			sample = float32(math.Abs(float64(1.0-fp*fp*2)))*2 - 1
+		case 9, 10: // 1-bit LFSR noise, long or short
+			sample = s.noise_buffer[s.phase*32/s.period]
+		}
+		// lp filter
+		pp := s.fltp
+		s.fltw *= s.fltw_d
+		if s.fltw < 0.0 {
+			s.fltw = 0.0
+		}
+		if s.fltw > 0.1 {
+			s.fltw = 0.1
+		}
+		if p.LpfFreq != 1.0 {
+			s.fltdp += (sample - s.fltp) * s.fltw
+			s.fltdp -= s.fltdp * s.fltdmp
+		} else {
+			s.fltp = sample
+			s.fltdp = 0.0
+		}
+		s.fltp += s.fltdp
+		// hp filter
+		s.fltphp += s.fltp - pp
+		s.fltphp -= s.fltphp * s.flthp
+		sample = s.fltphp
+		// phaser
+		s.phaser_buffer[s.ipp&1023] = sample
+		sample += s.phaser_buffer[(s.ipp-s.iphase+1024)&1023]
+		s.ipp = (s.ipp + 1) & 1023
+		// final accumulation and envelope application
+		ssample += sample * s.env_vol
+	}
+	ssample /= 8
+	ssample = s.crush(ssample)
+	ssample = s.compress(ssample)
+	ssample *= s.MasterVol
+
+	ssample *= 2.0 * p.SoundVol
+
+	return ssample
+}
@@ -0,0 +1,44 @@
+package sfxr
+
+import (
+	"math"
+	"time"
+)
+
+// trimSilence removes the leading and trailing samples quieter than
+// threshold dBFS.
+func trimSilence(samples []float32, threshold float64) []float32 {
+	level := float32(math.Pow(10, threshold/20))
+	loud := func(v float32) bool {
+		return v >= level || v <= -level
+	}
+	start := 0
+	for start < len(samples) && !loud(samples[start]) {
+		start++
+	}
+	end := len(samples)
+	for end > start && !loud(samples[end-1]) {
+		end--
+	}
+	return samples[start:end]
+}
+
+// fade applies linear fade-in and fade-out ramps of the given durations to
+// samples at freq. Ramps longer than the sound are shortened to fit.
+func fade(samples []float32, freq int, in, out time.Duration) {
+	ramp := func(d time.Duration) int {
+		n := int(d.Seconds() * float64(freq))
+		return min(n, len(samples))
+	}
+	if n := ramp(in); n > 0 {
+		for i := range samples[:n] {
+			samples[i] *= float32(i) / float32(n)
+		}
+	}
+	if n := ramp(out); n > 0 {
+		tail := samples[len(samples)-n:]
+		for i := range tail {
+			tail[i] *= float32(n-1-i) / float32(n)
+		}
+	}
+}
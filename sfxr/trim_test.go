@@ -0,0 +1,76 @@
+package sfxr
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrimSilence(t *testing.T) {
+	samples := []float32{0, 0.0001, -0.01, 0.5, 0, -0.002, 0.0005, 0}
+	got := trimSilence(samples, -50) // about 0.0032
+	if len(got) != 2 || got[0] != -0.01 || got[1] != 0.5 {
+		t.Errorf("trimmed to %v", got)
+	}
+	if got := trimSilence(make([]float32, 10), -60); len(got) != 0 {
+		t.Errorf("silence trimmed to %d samples", len(got))
+	}
+}
+
+func TestFade(t *testing.T) {
+	samples := []float32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	fade(samples, 1000, 4*time.Millisecond, 2*time.Millisecond)
+	want := []float32{0, 0.25, 0.5, 0.75, 1, 1, 1, 1, 0.5, 0}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Fatalf("faded to %v, want %v", samples, want)
+		}
+	}
+	fade(samples[:1], 1000, time.Second, time.Second) // longer than the sound
+}
+
+func TestRenderTrimFade(t *testing.T) {
+	p := loadGolden(t, "sine_attack")
+	full, _, err := Render(p, 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSynth(p)
+	s.TrimThreshold = -40
+	s.FadeOut = 5 * time.Millisecond
+	var wav bytes.Buffer
+	if err := s.WriteWAV(&wav, 44100, 16); err != nil {
+		t.Fatal(err)
+	}
+	trimmed, _, err := s.Render(44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed) == 0 || len(trimmed) >= len(full) {
+		t.Fatalf("trimmed %d samples to %d", len(full), len(trimmed))
+	}
+	if math.Abs(float64(trimmed[0])) < 0.01 {
+		t.Errorf("first sample %v is below the threshold", trimmed[0])
+	}
+	if trimmed[len(trimmed)-1] != 0 {
+		t.Errorf("last sample %v not faded out", trimmed[len(trimmed)-1])
+	}
+	if r := s.Report(); r.Samples != len(trimmed) {
+		t.Errorf("report has %d samples, want %d", r.Samples, len(trimmed))
+	}
+
+	pcm, err := s.PCM(44100, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(pcm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, wav.Bytes()[wavHeaderSize:]) {
+		t.Error("PCM stream differs from WAV data")
+	}
+}
@@ -0,0 +1,110 @@
+package sfxr
+
+import (
+	"fmt"
+	"math"
+)
+
+// waveNames names the waveforms in WaveType order.
+var waveNames = [...]string{
+	"square", "sawtooth", "sine", "noise",
+	"triangle", "pink noise", "tan", "whistle", "breaker",
+	"LFSR noise", "short LFSR noise",
+}
+
+const waveTypeCount = len(waveNames)
+
+type paramField struct {
+	name    string
+	value   *float32
+	bipolar bool
+}
+
+// fields lists the float parameters with their legal range, which matches
+// the editor's sliders: unipolar parameters lie in [0, 1] and bipolar ones
+// in [-1, 1].
+func (p *Params) fields() []paramField {
+	return []paramField{
+		{"base_freq", &p.BaseFreq, false},
+		{"freq_limit", &p.FreqLimit, false},
+		{"freq_ramp", &p.FreqRamp, true},
+		{"freq_dramp", &p.FreqDramp, true},
+		{"duty", &p.Duty, false},
+		{"duty_ramp", &p.DutyRamp, true},
+		{"vib_strength", &p.VibStrength, false},
+		{"vib_speed", &p.VibSpeed, false},
+		{"vib_delay", &p.VibDelay, false},
+		{"env_attack", &p.EnvAttack, false},
+		{"env_sustain", &p.EnvSustain, false},
+		{"env_decay", &p.EnvDecay, false},
+		{"env_punch", &p.EnvPunch, false},
+		{"lpf_resonance", &p.LpfResonance, false},
+		{"lpf_freq", &p.LpfFreq, false},
+		{"lpf_ramp", &p.LpfRamp, true},
+		{"hpf_freq", &p.HpfFreq, false},
+		{"hpf_ramp", &p.HpfRamp, true},
+		{"pha_offset", &p.PhaOffset, true},
+		{"pha_ramp", &p.PhaRamp, true},
+		{"repeat_speed", &p.RepeatSpeed, false},
+		{"arp_speed", &p.ArpSpeed, false},
+		{"arp_mod", &p.ArpMod, true},
+		{"crush_bits", &p.CrushBits, false},
+		{"crush_bits_ramp", &p.CrushBitsRamp, true},
+		{"crush_rate", &p.CrushRate, false},
+		{"crush_rate_ramp", &p.CrushRateRamp, true},
+		{"compression", &p.Compression, false},
+		{"makeup", &p.Makeup, false},
+		{"sound_vol", &p.SoundVol, false},
+	}
+}
+
+func (f paramField) min() float32 {
+	if f.bipolar {
+		return -1.0
+	}
+	return 0.0
+}
+
+// Validate reports the first parameter that is out of range or not a number.
+func (p *Params) Validate() error {
+	if p.WaveType < 0 || p.WaveType >= waveTypeCount {
+		return fmt.Errorf("wave_type %d out of range", p.WaveType)
+	}
+	for _, f := range p.fields() {
+		v := float64(*f.value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%s is %v", f.name, v)
+		}
+		if *f.value < f.min() || *f.value > 1.0 {
+			return fmt.Errorf("%s %v out of range [%v, 1]", f.name, v, f.min())
+		}
+	}
+	return nil
+}
+
+// Sanitize clamps every parameter into its legal range and replaces values
+// that are not finite numbers with their defaults. It returns a warning for
+// each parameter it changed.
+func (p *Params) Sanitize() (warnings []string) {
+	def := DefaultParams()
+	defFields := def.fields()
+	if p.WaveType < 0 || p.WaveType >= waveTypeCount {
+		warnings = append(warnings, fmt.Sprintf("wave_type %d out of range, using %d", p.WaveType, def.WaveType))
+		p.WaveType = def.WaveType
+	}
+	for i, f := range p.fields() {
+		v := *f.value
+		switch {
+		case math.IsNaN(float64(v)) || math.IsInf(float64(v), 0):
+			*f.value = *defFields[i].value
+			warnings = append(warnings, fmt.Sprintf("%s is %v, using %v", f.name, v, *f.value))
+		case v < f.min():
+			*f.value = f.min()
+			warnings = append(warnings, fmt.Sprintf("%s %v clamped to %v", f.name, v, *f.value))
+		case v > 1.0:
+			*f.value = 1.0
+			warnings = append(warnings, fmt.Sprintf("%s %v clamped to %v", f.name, v, *f.value))
+		}
+	}
+	return warnings
+}
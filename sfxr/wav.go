@@ -0,0 +1,114 @@
+package sfxr
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ExportWAV renders the sound to a mono WAV file at the given sample rate
+// (8000 to 192000 Hz) and bit depth: 8, 16 or 24-bit integer PCM, or 32-bit
+// float.
+func (s *Synth) ExportWAV(filename string, freq, bits int) error {
+	if err := checkRate(freq); err != nil {
+		return err
+	}
+	if err := checkBits(bits); err != nil {
+		return err
+	}
+
+	err := replaceFile(filename, func(w io.Writer) error {
+		return s.WriteWAV(w, freq, bits)
+	})
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", filename, err)
+	}
+	return nil
+}
+
+// WriteWAV renders the sound and writes it to w as a mono WAV file, like
+// ExportWAV. The sound is rendered in memory first, because the header
+// holds its size.
+func (s *Synth) WriteWAV(w io.Writer, freq, bits int) error {
+	if err := checkBits(bits); err != nil {
+		return err
+	}
+	samples, _, err := s.Render(freq)
+	if err != nil {
+		return err
+	}
+	q := newQuantizer(bits, s.Dither, s.Params.Seed)
+	data := make([]byte, 0, len(samples)*bits/8)
+	for _, v := range samples {
+		data = q.append(data, v)
+	}
+
+	// Buffered writes keep the first error, which is reported by Flush.
+	foutput := bufio.NewWriter(w)
+
+	// 8 and 16-bit files use the plain PCM header that every reader knows.
+	// 24-bit files use the extensible header, and float files the IEEE
+	// float format with the fact chunk it requires.
+	fmtsize := 16
+	switch bits {
+	case 24:
+		fmtsize = 40
+	case 32:
+		fmtsize = 18
+	}
+	riffsize := 4 + 8 + fmtsize + 8 + len(data) + len(data)%2
+	if bits == 32 {
+		riffsize += 12
+	}
+
+	// write wav header
+	foutput.Write([]byte("RIFF"))
+	binary.Write(foutput, binary.LittleEndian, uint32(riffsize)) // remaining file size
+	foutput.Write([]byte("WAVE"))
+
+	foutput.Write([]byte("fmt "))
+	binary.Write(foutput, binary.LittleEndian, uint32(fmtsize)) // chunk size
+	switch bits {
+	case 24:
+		binary.Write(foutput, binary.LittleEndian, uint16(0xFFFE)) // WAVE_FORMAT_EXTENSIBLE
+	case 32:
+		binary.Write(foutput, binary.LittleEndian, uint16(3)) // WAVE_FORMAT_IEEE_FLOAT
+	default:
+		binary.Write(foutput, binary.LittleEndian, uint16(1)) // compression code
+	}
+	binary.Write(foutput, binary.LittleEndian, uint16(1))           // channels
+	binary.Write(foutput, binary.LittleEndian, uint32(freq))        // sample rate
+	binary.Write(foutput, binary.LittleEndian, uint32(freq*bits/8)) // bytes/sec
+	binary.Write(foutput, binary.LittleEndian, uint16(bits/8))      // block align
+	binary.Write(foutput, binary.LittleEndian, uint16(bits))        // bits per sample
+	switch bits {
+	case 24:
+		binary.Write(foutput, binary.LittleEndian, uint16(22))   // extension size
+		binary.Write(foutput, binary.LittleEndian, uint16(bits)) // valid bits per sample
+		binary.Write(foutput, binary.LittleEndian, uint32(4))    // channel mask: front center
+		foutput.Write(pcmSubFormat)
+	case 32:
+		binary.Write(foutput, binary.LittleEndian, uint16(0)) // extension size
+
+		foutput.Write([]byte("fact"))
+		binary.Write(foutput, binary.LittleEndian, uint32(4))            // chunk size
+		binary.Write(foutput, binary.LittleEndian, uint32(len(samples))) // sample frames
+	}
+
+	foutput.Write([]byte("data"))
+	binary.Write(foutput, binary.LittleEndian, uint32(len(data))) // chunk size
+
+	foutput.Write(data)
+	if len(data)%2 != 0 {
+		foutput.WriteByte(0) // chunks are padded to an even size
+	}
+	return foutput.Flush()
+}
+
+// pcmSubFormat is KSDATAFORMAT_SUBTYPE_PCM, the GUID that marks integer PCM
+// data in an extensible header.
+var pcmSubFormat = []byte{
+	0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00,
+	0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71,
+}
@@ -0,0 +1,92 @@
+package sfxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// wavData checks the RIFF structure of a WAV file and returns its format
+// code, bits per sample and sample data.
+func wavData(t *testing.T, wav []byte) (format, bits int, data []byte) {
+	t.Helper()
+	if string(wav[:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		t.Fatal("not a WAV file")
+	}
+	if size := int(binary.LittleEndian.Uint32(wav[4:])); size != len(wav)-8 {
+		t.Fatalf("RIFF size %d, want %d", size, len(wav)-8)
+	}
+	for off := 12; off+8 <= len(wav); {
+		id, size := string(wav[off:off+4]), int(binary.LittleEndian.Uint32(wav[off+4:]))
+		chunk := wav[off+8 : off+8+size]
+		switch id {
+		case "fmt ":
+			format = int(binary.LittleEndian.Uint16(chunk))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:]))
+		case "data":
+			data = chunk
+		}
+		off += 8 + size + size%2
+	}
+	return format, bits, data
+}
+
+func TestWAVFormats(t *testing.T) {
+	p := loadGolden(t, "saw_slide")
+	want, _, err := Render(p, 48000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		bits, format int
+		step         float64 // largest difference after quantization
+	}{
+		{8, 1, 1.0 / 127},
+		{16, 1, 1.0 / 32767},
+		{24, 0xFFFE, 1.0 / 8388607},
+		{32, 3, 0},
+	} {
+		var wav bytes.Buffer
+		if err := NewSynth(p).WriteWAV(&wav, 48000, tc.bits); err != nil {
+			t.Fatal(err)
+		}
+		format, bits, data := wavData(t, wav.Bytes())
+		if format != tc.format || bits != tc.bits {
+			t.Errorf("%d bits: format %#x with %d bits, want %#x", tc.bits, format, bits, tc.format)
+		}
+		if len(data) != len(want)*tc.bits/8 {
+			t.Fatalf("%d bits: %d bytes of data, want %d", tc.bits, len(data), len(want)*tc.bits/8)
+		}
+		for i, v := range want {
+			var got float64
+			switch tc.bits {
+			case 8:
+				got = (float64(data[i]) - 128) / 127
+			case 16:
+				got = float64(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32767
+			case 24:
+				x := int32(data[i*3]) | int32(data[i*3+1])<<8 | int32(int8(data[i*3+2]))<<16
+				got = float64(x) / 8388607
+			case 32:
+				got = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:])))
+			}
+			if math.Abs(got-float64(v)) > tc.step {
+				t.Fatalf("%d bits: sample %d is %v, want %v", tc.bits, i, got, v)
+			}
+		}
+	}
+}
+
+func TestGain(t *testing.T) {
+	p := loadGolden(t, "sine_attack")
+	loud, _, _ := Render(p, 44100)
+	s := NewSynth(p)
+	s.Gain = DefaultGain / 2
+	quiet, _, _ := s.Render(44100)
+	for i := range loud {
+		if loud[i] > -1 && loud[i] < 1 && math.Abs(float64(loud[i]-2*quiet[i])) > 1e-6 {
+			t.Fatalf("sample %d: %v at half gain, want %v", i, quiet[i], loud[i]/2)
+		}
+	}
+}
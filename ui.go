@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
-	"math"
 	"strings"
+	"time"
 
+	"github.com/arthrp/sfxr-go/sfxr"
 	"github.com/ncruces/zenity"
+	"github.com/veandco/go-sdl2/sdl"
 )
 
 var (
@@ -26,6 +28,24 @@ var (
 	firstframe      bool = true
 	refresh_counter int  = 0
 	drawcount       int  = 0
+
+	status_text  string
+	status_color uint32
+	status_until time.Time
+
+	// show_effects swaps the synth sliders for the effect sliders.
+	show_effects bool
+
+	// wave_names labels the waveform buttons, in WaveType order.
+	wave_names = []string{"SQUARE", "SAWTOOTH", "SINE", "NOISE", "TRIANGLE", "PINK", "TAN", "WHISTLE", "BREAKER", "LFSR", "PERIODIC"}
+
+	// clip_report is the export report for clip_params at clip_freq and
+	// clip_bits, which is rendered again whenever any of them changes.
+	clip_params  sfxr.Params
+	clip_freq    int
+	clip_bits    int
+	clip_report  sfxr.Report
+	clip_checked bool
 )
 
 func ClearScreen(color uint32) {
@@ -99,6 +119,64 @@ func DrawText(sx, sy int, color uint32, format string, args ...interface{}) {
 	}
 }
 
+// ShowMessage prints a message to the console and shows it at the bottom of
+// the window for a few seconds.
+func ShowMessage(color uint32, format string, args ...interface{}) {
+	status_text = fmt.Sprintf(format, args...)
+	status_color = color
+	status_until = time.Now().Add(5 * time.Second)
+	fmt.Println(status_text)
+}
+
+func ShowError(err error) {
+	ShowMessage(0xA00000, "%v", err)
+}
+
+// ShowWarnings prints every warning to the console and shows the first one
+// on screen.
+func ShowWarnings(warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	for _, w := range warnings[1:] {
+		fmt.Println(w)
+	}
+	if len(warnings) > 1 {
+		ShowMessage(0x000000, "%s (+%d more)", warnings[0], len(warnings)-1)
+	} else {
+		ShowMessage(0x000000, "%s", warnings[0])
+	}
+}
+
+func DrawStatus() {
+	// the font only has glyphs from ' ' to '_'
+	text := []rune(strings.ToUpper(status_text))
+	for i, c := range text {
+		if c < ' ' || c > '_' {
+			text[i] = '?'
+		}
+	}
+	if len(text) > 64 {
+		text = append(text[:61], '.', '.', '.')
+	}
+	DrawText(120, 466, status_color, "%s", string(text))
+}
+
+// UpdateClipCheck renders the sound as it would be exported, to find out
+// whether it clips. It waits while a slider is being dragged.
+func UpdateClipCheck(p *sfxr.Params) {
+	if mouse_left || (clip_checked && *p == clip_params && wav_freq == clip_freq && wav_bits == clip_bits) {
+		return
+	}
+	check := sfxr.NewSynth(*p)
+	check.Render(wav_freq)
+	clip_params = *p
+	clip_freq = wav_freq
+	clip_bits = wav_bits
+	clip_report = check.Report()
+	clip_checked = true
+}
+
 func MouseInBox(x, y, w, h int) bool {
 	if mouse_x >= x && mouse_x < x+w && mouse_y >= y && mouse_y < y+h {
 		return true
@@ -149,17 +227,22 @@ func Slider(x, y int, value *float32, bipolar bool, text string) {
 		DrawBar(x+50, y+8, 1, 3, 0x000000)
 	}
 	tcol := uint32(0x000000)
-	if wave_type != 0 && (value == &p_duty || value == &p_duty_ramp) {
+	if synth.Params.WaveType != 0 && (value == &synth.Params.Duty || value == &synth.Params.DutyRamp) {
 		tcol = 0x808080
 	}
 	DrawText(x-4-len(text)*8, y+1, tcol, text)
 }
 
 func Button(x, y int, highlight bool, text string, id int) bool {
+	return ButtonW(x, y, 100, highlight, text, id)
+}
+
+// ButtonW is a Button w pixels wide.
+func ButtonW(x, y, w int, highlight bool, text string, id int) bool {
 	color1 := uint32(0x000000)
 	color2 := uint32(0xA09088)
 	color3 := uint32(0x000000)
-	hover := MouseInBox(x, y, 100, 17)
+	hover := MouseInBox(x, y, w, 17)
 	if hover && mouse_leftclick {
 		vcurbutton = id
 	}
@@ -174,8 +257,8 @@ func Button(x, y int, highlight bool, text string, id int) bool {
 		color2 = 0xFFF0E0
 		color3 = 0xA09088
 	}
-	DrawBar(x-1, y-1, 102, 19, color1)
-	DrawBar(x, y, 100, 17, color2)
+	DrawBar(x-1, y-1, w+2, 19, color1)
+	DrawBar(x, y, w, 17, color2)
 	DrawText(x+5, y+5, color3, text)
 	if current && hover && !mouse_left {
 		return true
@@ -200,7 +283,7 @@ func DrawScreen() {
 		redraw = true
 	}
 
-	if playing_sample {
+	if synth.Playing() {
 		redraw = true
 	}
 
@@ -216,160 +299,15 @@ func DrawScreen() {
 
 	firstframe = false
 
+	p := &synth.Params
+
 	ClearScreen(0xC0B090)
 
 	DrawText(10, 10, 0x504030, "GENERATOR")
-	for i := 0; i < 7; i++ {
-		if Button(5, 35+i*30, false, categories[i].Name, 300+i) {
-			switch i {
-			case 0: // pickup/coin
-				ResetParams()
-				p_base_freq = 0.4 + frnd(0.5)
-				p_env_attack = 0.0
-				p_env_sustain = frnd(0.1)
-				p_env_decay = 0.1 + frnd(0.4)
-				p_env_punch = 0.3 + frnd(0.3)
-				if rnd(1) != 0 {
-					p_arp_speed = 0.5 + frnd(0.2)
-					p_arp_mod = 0.2 + frnd(0.4)
-				}
-			case 1: // laser/shoot
-				ResetParams()
-				wave_type = rnd(2)
-				if wave_type == 2 && rnd(1) != 0 {
-					wave_type = rnd(1)
-				}
-				p_base_freq = 0.5 + frnd(0.5)
-				p_freq_limit = p_base_freq - 0.2 - frnd(0.6)
-				if p_freq_limit < 0.2 {
-					p_freq_limit = 0.2
-				}
-				p_freq_ramp = -0.15 - frnd(0.2)
-				if rnd(2) == 0 {
-					p_base_freq = 0.3 + frnd(0.6)
-					p_freq_limit = frnd(0.1)
-					p_freq_ramp = -0.35 - frnd(0.3)
-				}
-				if rnd(1) != 0 {
-					p_duty = frnd(0.5)
-					p_duty_ramp = frnd(0.2)
-				} else {
-					p_duty = 0.4 + frnd(0.5)
-					p_duty_ramp = -frnd(0.7)
-				}
-				p_env_attack = 0.0
-				p_env_sustain = 0.1 + frnd(0.2)
-				p_env_decay = frnd(0.4)
-				if rnd(1) != 0 {
-					p_env_punch = frnd(0.3)
-				}
-				if rnd(2) == 0 {
-					p_pha_offset = frnd(0.2)
-					p_pha_ramp = -frnd(0.2)
-				}
-				if rnd(1) != 0 {
-					p_hpf_freq = frnd(0.3)
-				}
-			case 2: // explosion
-				ResetParams()
-				wave_type = 3
-				if rnd(1) != 0 {
-					p_base_freq = 0.1 + frnd(0.4)
-					p_freq_ramp = -0.1 + frnd(0.4)
-				} else {
-					p_base_freq = 0.2 + frnd(0.7)
-					p_freq_ramp = -0.2 - frnd(0.2)
-				}
-				p_base_freq *= p_base_freq
-				if rnd(4) == 0 {
-					p_freq_ramp = 0.0
-				}
-				if rnd(2) == 0 {
-					p_repeat_speed = 0.3 + frnd(0.5)
-				}
-				p_env_attack = 0.0
-				p_env_sustain = 0.1 + frnd(0.3)
-				p_env_decay = frnd(0.5)
-				if rnd(1) == 0 {
-					p_pha_offset = -0.3 + frnd(0.9)
-					p_pha_ramp = -frnd(0.3)
-				}
-				p_env_punch = 0.2 + frnd(0.6)
-				if rnd(1) != 0 {
-					p_vib_strength = frnd(0.7)
-					p_vib_speed = frnd(0.6)
-				}
-				if rnd(2) == 0 {
-					p_arp_speed = 0.6 + frnd(0.3)
-					p_arp_mod = 0.8 - frnd(1.6)
-				}
-			case 3: // powerup
-				ResetParams()
-				if rnd(1) != 0 {
-					wave_type = 1
-				} else {
-					p_duty = frnd(0.6)
-				}
-				if rnd(1) != 0 {
-					p_base_freq = 0.2 + frnd(0.3)
-					p_freq_ramp = 0.1 + frnd(0.4)
-					p_repeat_speed = 0.4 + frnd(0.4)
-				} else {
-					p_base_freq = 0.2 + frnd(0.3)
-					p_freq_ramp = 0.05 + frnd(0.2)
-					if rnd(1) != 0 {
-						p_vib_strength = frnd(0.7)
-						p_vib_speed = frnd(0.6)
-					}
-				}
-				p_env_attack = 0.0
-				p_env_sustain = frnd(0.4)
-				p_env_decay = 0.1 + frnd(0.4)
-			case 4: // hit/hurt
-				ResetParams()
-				wave_type = rnd(2)
-				if wave_type == 2 {
-					wave_type = 3
-				}
-				if wave_type == 0 {
-					p_duty = frnd(0.6)
-				}
-				p_base_freq = 0.2 + frnd(0.6)
-				p_freq_ramp = -0.3 - frnd(0.4)
-				p_env_attack = 0.0
-				p_env_sustain = frnd(0.1)
-				p_env_decay = 0.1 + frnd(0.2)
-				if rnd(1) != 0 {
-					p_hpf_freq = frnd(0.3)
-				}
-			case 5: // jump
-				ResetParams()
-				wave_type = 0
-				p_duty = frnd(0.6)
-				p_base_freq = 0.3 + frnd(0.3)
-				p_freq_ramp = 0.1 + frnd(0.2)
-				p_env_attack = 0.0
-				p_env_sustain = 0.1 + frnd(0.3)
-				p_env_decay = 0.1 + frnd(0.2)
-				if rnd(1) != 0 {
-					p_hpf_freq = frnd(0.3)
-				}
-				if rnd(1) != 0 {
-					p_lpf_freq = 1.0 - frnd(0.6)
-				}
-			case 6: // blip/select
-				ResetParams()
-				wave_type = rnd(1)
-				if wave_type == 0 {
-					p_duty = frnd(0.6)
-				}
-				p_base_freq = 0.2 + frnd(0.4)
-				p_env_attack = 0.0
-				p_env_sustain = 0.1 + frnd(0.1)
-				p_env_decay = frnd(0.2)
-				p_hpf_freq = 0.1
-			}
-			PlaySample()
+	for i, c := range sfxr.Categories {
+		if Button(5, 35+i*30, false, c.String(), 300+i) {
+			generator.Generate(p, c)
+			synth.PlaySample()
 		}
 	}
 
@@ -377,162 +315,97 @@ func DrawScreen() {
 	DrawText(120, 10, 0x504030, "MANUAL SETTINGS")
 	DrawSprite(&ld48, 8, 440, 0, 0xB0A080)
 
-	if Button(130, 30, wave_type == 0, "SQUAREWAVE", 10) {
-		wave_type = 0
-	}
-	if Button(250, 30, wave_type == 1, "SAWTOOTH", 11) {
-		wave_type = 1
-	}
-	if Button(370, 30, wave_type == 2, "SINEWAVE", 12) {
-		wave_type = 2
+	for i, name := range wave_names {
+		if ButtonW(125+i%6*80, 22+i/6*20, 76, p.WaveType == i, name, 50+i) {
+			p.WaveType = i
+		}
 	}
-	if Button(490, 30, wave_type == 3, "NOISE", 13) {
-		wave_type = 3
+	if ButtonW(525, 42, 76, p.BandLimited, "HQ OSC", 61) {
+		p.BandLimited = !p.BandLimited
 	}
 
 	do_play := false
 
 	DrawBar(5-1-1, 412-1-1, 102+2, 19+2, 0x000000)
 	if Button(5, 412, false, "RANDOMIZE", 40) {
-		p_base_freq = float32(math.Pow(float64(frnd(2.0)-1.0), 2.0))
-		if rnd(1) != 0 {
-			p_base_freq = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0)) + 0.5
-		}
-		p_freq_limit = 0.0
-		p_freq_ramp = float32(math.Pow(float64(frnd(2.0)-1.0), 5.0))
-		if p_base_freq > 0.7 && p_freq_ramp > 0.2 {
-			p_freq_ramp = -p_freq_ramp
-		}
-		if p_base_freq < 0.2 && p_freq_ramp < -0.05 {
-			p_freq_ramp = -p_freq_ramp
-		}
-		p_freq_dramp = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_duty = frnd(2.0) - 1.0
-		p_duty_ramp = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_vib_strength = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_vib_speed = frnd(2.0) - 1.0
-		p_vib_delay = frnd(2.0) - 1.0
-		p_env_attack = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_env_sustain = float32(math.Pow(float64(frnd(2.0)-1.0), 2.0))
-		p_env_decay = frnd(2.0) - 1.0
-		p_env_punch = float32(math.Pow(float64(frnd(0.8)), 2.0))
-		if p_env_attack+p_env_sustain+p_env_decay < 0.2 {
-			p_env_sustain += 0.2 + frnd(0.3)
-			p_env_decay += 0.2 + frnd(0.3)
-		}
-		p_lpf_resonance = frnd(2.0) - 1.0
-		p_lpf_freq = 1.0 - float32(math.Pow(float64(frnd(1.0)), 3.0))
-		p_lpf_ramp = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		if p_lpf_freq < 0.1 && p_lpf_ramp < -0.05 {
-			p_lpf_ramp = -p_lpf_ramp
-		}
-		p_hpf_freq = float32(math.Pow(float64(frnd(1.0)), 5.0))
-		p_hpf_ramp = float32(math.Pow(float64(frnd(2.0)-1.0), 5.0))
-		p_pha_offset = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_pha_ramp = float32(math.Pow(float64(frnd(2.0)-1.0), 3.0))
-		p_repeat_speed = frnd(2.0) - 1.0
-		p_arp_speed = frnd(2.0) - 1.0
-		p_arp_mod = frnd(2.0) - 1.0
+		generator.Randomize(p)
 		do_play = true
 	}
 
 	if Button(5, 382, false, "MUTATE", 30) {
-		if rnd(1) != 0 {
-			p_base_freq += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_freq_ramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_freq_dramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_duty += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_duty_ramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_vib_strength += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_vib_speed += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_vib_delay += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_env_attack += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_env_sustain += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_env_decay += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_env_punch += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_lpf_resonance += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_lpf_freq += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_lpf_ramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_hpf_freq += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_hpf_ramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_pha_offset += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_pha_ramp += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_repeat_speed += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_arp_speed += frnd(0.1) - 0.05
-		}
-		if rnd(1) != 0 {
-			p_arp_mod += frnd(0.1) - 0.05
-		}
+		generator.Mutate(p)
 		do_play = true
 	}
 
 	DrawText(515, 170, 0x000000, "VOLUME")
+	UpdateClipCheck(p)
+	if clip_report.Clipped > 0 {
+		DrawText(570, 170, 0xFF0000, "CLIP")
+	}
 	DrawBar(490-1-1+60, 180-1+5, 70, 2, 0x000000)
 	DrawBar(490-1-1+60+68, 180-1+5, 2, 205, 0x000000)
 	DrawBar(490-1-1+60, 180-1, 42+2, 10+2, 0xFF0000)
-	Slider(490, 180, &sound_vol, false, " ")
+	Slider(490, 180, &p.SoundVol, false, " ")
 	if Button(490, 200, false, "PLAY SOUND", 20) {
-		PlaySample()
+		synth.PlaySample()
+	}
+
+	if Button(490, 230, false, "COPY JSFXR", 21) {
+		share, warnings := p.JsfxrB58()
+		sdl.SetClipboardText(share)
+		ShowWarnings(warnings)
+	}
+	if Button(490, 260, false, "PASTE JSFXR", 22) {
+		text, err := sdl.GetClipboardText()
+		var warnings []string
+		if err == nil {
+			warnings, err = p.ImportJsfxr(text)
+		}
+		if err != nil {
+			ShowError(fmt.Errorf("paste failed: %w", err))
+		} else {
+			ShowWarnings(warnings)
+			synth.PlaySample()
+		}
 	}
 
 	if Button(490, 290, false, "LOAD SOUND", 14) {
 		filename, err := zenity.SelectFile(
 			zenity.Title("Load sound settings"),
-			zenity.FileFilter{Name: "CFG files", Patterns: []string{"*.cfg"}},
+			zenity.FileFilter{Name: "Sound settings", Patterns: []string{"*.cfg", "*.json", "*.bfxrsound"}},
 		)
 		if err == nil && filename != "" {
-			ResetParams()
-			LoadSettings(filename)
-			PlaySample()
+			q := *p
+			q.Reset()
+			var warnings []string
+			if strings.HasSuffix(strings.ToLower(filename), ".bfxrsound") {
+				warnings, err = q.LoadBfxr(filename)
+			} else {
+				warnings, err = q.LoadSettings(filename)
+			}
+			if err != nil {
+				ShowError(err)
+			} else {
+				*p = q
+				ShowWarnings(warnings)
+				synth.PlaySample()
+			}
 		}
 	}
 	if Button(490, 320, false, "SAVE SOUND", 15) {
 		filename, err := zenity.SelectFileSave(
 			zenity.Title("Save sound settings"),
-			zenity.FileFilter{Name: "CFG files", Patterns: []string{"*.cfg"}},
+			zenity.FileFilters{
+				{Name: "CFG files", Patterns: []string{"*.cfg"}},
+				{Name: "JSON files", Patterns: []string{"*.json"}},
+			},
 		)
 		if err == nil && filename != "" {
-			SaveSettings(filename)
+			if err := p.SaveSettings(filename); err != nil {
+				ShowError(err)
+			} else {
+				ShowMessage(0x000000, "Saved %s", filename)
+			}
 		}
 	}
 
@@ -547,110 +420,173 @@ func DrawScreen() {
 			if !strings.HasSuffix(strings.ToLower(filename), ".wav") {
 				filename += ".wav"
 			}
-			if ExportWAV(filename) {
-				fmt.Printf("Exported to %s\n", filename)
+			export := sfxr.NewSynth(*p)
+			export.Dither = wav_dither
+			if err := export.ExportWAV(filename, wav_freq, wav_bits); err != nil {
+				ShowError(fmt.Errorf("export failed: %w", err))
 			} else {
-				fmt.Printf("Export failed\n")
+				report := export.Report()
+				if report.Clipped > 0 {
+					ShowMessage(0xA00000, "Exported to %s, %d samples clipped", filename, report.Clipped)
+				} else {
+					ShowMessage(0x000000, "Exported to %s (peak %.1f dBFS, %.1f LUFS)", filename, report.Levels.Peak, report.Levels.Loudness)
+				}
 			}
 		}
 	}
 
-	str := fmt.Sprintf("%d HZ", wav_freq)
+	str := []string{"NO DITHER", "TPDF DITHER", "SHAPED TPDF"}[wav_dither]
+	if Button(490, 350, false, str, 17) {
+		wav_dither = (wav_dither + 1) % 3
+	}
+	str = fmt.Sprintf("%d HZ", wav_freq)
 	if Button(490, 410, false, str, 18) {
-		if wav_freq == 44100 {
-			wav_freq = 22050
-		} else {
-			wav_freq = 44100
+		i := 0
+		for i < len(wav_rates)-1 && wav_rates[i] != wav_freq {
+			i++
 		}
+		wav_freq = wav_rates[(i+1)%len(wav_rates)]
 	}
 	str = fmt.Sprintf("%d-BIT", wav_bits)
+	if wav_bits == 32 {
+		str = "FLOAT"
+	}
 	if Button(490, 440, false, str, 19) {
-		if wav_bits == 16 {
+		switch wav_bits {
+		case 16:
+			wav_bits = 24
+		case 24:
+			wav_bits = 32
+		case 32:
 			wav_bits = 8
-		} else {
+		default:
 			wav_bits = 16
 		}
 	}
 
+	if Button(490, 140, show_effects, "EFFECTS", 62) {
+		show_effects = !show_effects
+	}
+
 	ypos := 4
 	xpos := 350
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
+
+	if show_effects {
+		ypos = DrawEffectSliders(p, xpos, ypos)
+	} else {
+		ypos = DrawSynthSliders(p, xpos, ypos)
+	}
+
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
+
+	DrawBar(xpos-190, 4*17-5, 1, (ypos-4)*17, 0x000000)
+	DrawBar(xpos-190+299, 4*17-5, 1, (ypos-4)*17, 0x000000)
 
-	Slider(xpos, ypos*18, &p_env_attack, false, "ATTACK TIME")
+	if time.Now().Before(status_until) {
+		DrawStatus()
+	}
+
+	if do_play {
+		synth.PlaySample()
+	}
+
+	if !mouse_left {
+		vcurbutton = -1
+	}
+}
+
+// DrawSynthSliders draws the sliders of the classic sfxr parameters from row
+// ypos down and returns the row after the last one.
+func DrawSynthSliders(p *sfxr.Params, xpos, ypos int) int {
+	Slider(xpos, ypos*17, &p.EnvAttack, false, "ATTACK TIME")
 	ypos++
-	Slider(xpos, ypos*18, &p_env_sustain, false, "SUSTAIN TIME")
+	Slider(xpos, ypos*17, &p.EnvSustain, false, "SUSTAIN TIME")
 	ypos++
-	Slider(xpos, ypos*18, &p_env_punch, false, "SUSTAIN PUNCH")
+	Slider(xpos, ypos*17, &p.EnvPunch, false, "SUSTAIN PUNCH")
 	ypos++
-	Slider(xpos, ypos*18, &p_env_decay, false, "DECAY TIME")
+	Slider(xpos, ypos*17, &p.EnvDecay, false, "DECAY TIME")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_base_freq, false, "START FREQUENCY")
+	Slider(xpos, ypos*17, &p.BaseFreq, false, "START FREQUENCY")
 	ypos++
-	Slider(xpos, ypos*18, &p_freq_limit, false, "MIN FREQUENCY")
+	Slider(xpos, ypos*17, &p.FreqLimit, false, "MIN FREQUENCY")
 	ypos++
-	Slider(xpos, ypos*18, &p_freq_ramp, true, "SLIDE")
+	Slider(xpos, ypos*17, &p.FreqRamp, true, "SLIDE")
 	ypos++
-	Slider(xpos, ypos*18, &p_freq_dramp, true, "DELTA SLIDE")
+	Slider(xpos, ypos*17, &p.FreqDramp, true, "DELTA SLIDE")
 	ypos++
 
-	Slider(xpos, ypos*18, &p_vib_strength, false, "VIBRATO DEPTH")
+	Slider(xpos, ypos*17, &p.VibStrength, false, "VIBRATO DEPTH")
 	ypos++
-	Slider(xpos, ypos*18, &p_vib_speed, false, "VIBRATO SPEED")
+	Slider(xpos, ypos*17, &p.VibSpeed, false, "VIBRATO SPEED")
+	ypos++
+	Slider(xpos, ypos*17, &p.VibDelay, false, "VIBRATO DELAY")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_arp_mod, true, "CHANGE AMOUNT")
+	Slider(xpos, ypos*17, &p.ArpMod, true, "CHANGE AMOUNT")
 	ypos++
-	Slider(xpos, ypos*18, &p_arp_speed, false, "CHANGE SPEED")
+	Slider(xpos, ypos*17, &p.ArpSpeed, false, "CHANGE SPEED")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_duty, false, "SQUARE DUTY")
+	Slider(xpos, ypos*17, &p.Duty, false, "SQUARE DUTY")
 	ypos++
-	Slider(xpos, ypos*18, &p_duty_ramp, true, "DUTY SWEEP")
+	Slider(xpos, ypos*17, &p.DutyRamp, true, "DUTY SWEEP")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_repeat_speed, false, "REPEAT SPEED")
+	Slider(xpos, ypos*17, &p.RepeatSpeed, false, "REPEAT SPEED")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_pha_offset, true, "PHASER OFFSET")
+	Slider(xpos, ypos*17, &p.PhaOffset, true, "PHASER OFFSET")
 	ypos++
-	Slider(xpos, ypos*18, &p_pha_ramp, true, "PHASER SWEEP")
+	Slider(xpos, ypos*17, &p.PhaRamp, true, "PHASER SWEEP")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	Slider(xpos, ypos*18, &p_lpf_freq, false, "LP FILTER CUTOFF")
+	Slider(xpos, ypos*17, &p.LpfFreq, false, "LP FILTER CUTOFF")
 	ypos++
-	Slider(xpos, ypos*18, &p_lpf_ramp, true, "LP FILTER CUTOFF SWEEP")
+	Slider(xpos, ypos*17, &p.LpfRamp, true, "LP FILTER CUTOFF SWEEP")
 	ypos++
-	Slider(xpos, ypos*18, &p_lpf_resonance, false, "LP FILTER RESONANCE")
+	Slider(xpos, ypos*17, &p.LpfResonance, false, "LP FILTER RESONANCE")
 	ypos++
-	Slider(xpos, ypos*18, &p_hpf_freq, false, "HP FILTER CUTOFF")
+	Slider(xpos, ypos*17, &p.HpfFreq, false, "HP FILTER CUTOFF")
 	ypos++
-	Slider(xpos, ypos*18, &p_hpf_ramp, true, "HP FILTER CUTOFF SWEEP")
+	Slider(xpos, ypos*17, &p.HpfRamp, true, "HP FILTER CUTOFF SWEEP")
 	ypos++
 
-	DrawBar(xpos-190, ypos*18-5, 300, 2, 0x000000)
+	return ypos
+}
+
+// DrawEffectSliders draws the sliders of the effects that follow the phaser,
+// which share the space of the synth sliders.
+func DrawEffectSliders(p *sfxr.Params, xpos, ypos int) int {
+	Slider(xpos, ypos*17, &p.CrushBits, false, "BIT CRUSH")
+	ypos++
+	Slider(xpos, ypos*17, &p.CrushBitsRamp, true, "BIT CRUSH SWEEP")
+	ypos++
+	Slider(xpos, ypos*17, &p.CrushRate, false, "SAMPLE HOLD")
+	ypos++
+	Slider(xpos, ypos*17, &p.CrushRateRamp, true, "SAMPLE HOLD SWEEP")
+	ypos++
 
-	DrawBar(xpos-190, 4*18-5, 1, (ypos-4)*18, 0x000000)
-	DrawBar(xpos-190+299, 4*18-5, 1, (ypos-4)*18, 0x000000)
+	DrawBar(xpos-190, ypos*17-5, 300, 2, 0x000000)
 
-	if do_play {
-		PlaySample()
-	}
+	Slider(xpos, ypos*17, &p.Compression, false, "COMPRESSION")
+	ypos++
+	Slider(xpos, ypos*17, &p.Makeup, false, "MAKEUP GAIN")
+	ypos++
 
-	if !mouse_left {
-		vcurbutton = -1
-	}
+	return ypos
 }